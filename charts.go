@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var eighths = []rune(" ▁▂▃▄▅▆▇█")
+
+// columnChart draws work and break side by side for each period, rows high, with a label under each
+func columnChart(labels []string, d [][2]time.Duration, rows int) string {
+	var top time.Duration
+	for _, v := range d {
+		top = max(top, v[0], v[1])
+	}
+	if top == 0 {
+		return ""
+	}
+	lines := make([]string, rows+1)
+	for r := range rows {
+		var b strings.Builder
+		if r == 0 {
+			fmt.Fprintf(&b, "%6s ", fmtDuration(top))
+		} else {
+			b.WriteString(strings.Repeat(" ", 7))
+		}
+		for _, v := range d {
+			for i, st := range []lipgloss.Style{barStyle, style} {
+				b.WriteString(st.Render(string(column(v[i], top, rows, rows-1-r))))
+			}
+			b.WriteString(" ")
+		}
+		lines[r] = b.String()
+	}
+	var b strings.Builder
+	b.WriteString(strings.Repeat(" ", 7))
+	for _, l := range labels {
+		fmt.Fprintf(&b, "%-3s", l)
+	}
+	lines[rows] = b.String() + "  " + barStyle.Render("█") + " work " + style.Render("█") + " break"
+	return strings.Join(lines, "\n")
+}
+
+// column is the block for one row of a bar, counting rows up from 0 at the bottom
+func column(d, top time.Duration, rows, row int) rune {
+	n := int(float64(d)/float64(top)*float64(rows*8)) - row*8
+	switch {
+	case n <= 0:
+		return ' '
+	case n >= 8:
+		return '█'
+	}
+	return eighths[n]
+}
+
+// sparkline draws a series of durations with a block each, scaled to the largest
+func sparkline(ds []time.Duration) string {
+	var top time.Duration
+	for _, d := range ds {
+		top = max(top, d)
+	}
+	rs := make([]rune, len(ds))
+	for i, d := range ds {
+		if top == 0 || d == 0 {
+			rs[i] = '·'
+			continue
+		}
+		rs[i] = eighths[1+int(float64(d)/float64(top)*7)]
+	}
+	return string(rs)
+}
+
+// daily sums work by date for the stints given
+func daily(es []entry) map[string]time.Duration {
+	ret := make(map[string]time.Duration)
+	for _, e := range es {
+		if e.typ == working {
+			ret[e.t.Local().Format(time.DateOnly)] += e.d
+		}
+	}
+	return ret
+}
+
+// sparkDays returns the work done on each of the days before today, over a number of weeks
+func (l *logger) sparkDays(weeks int) []time.Duration {
+	end := startOfDay(time.Now())
+	start := end.AddDate(0, 0, -7*weeks)
+	dd := daily(l.stints("", false, start, end))
+	ret := make([]time.Duration, 0, 7*weeks)
+	for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+		ret = append(ret, dd[d.Format(time.DateOnly)])
+	}
+	return ret
+}
+
+// sparkView shows daily work over the last few weeks, with today's live
+func (m model) sparkView() string {
+	if len(m.spark) == 0 {
+		return ""
+	}
+	var today time.Duration
+	for _, t := range m.tally {
+		today += t[0]
+	}
+	if s, ok := m.running(); ok && s == working {
+		today += m.stopwatch.Elapsed()
+	}
+	return fmt.Sprintf("Last %d weeks: %s", len(m.spark)/7, barStyle.Render(sparkline(append(m.spark, today))))
+}
+
+// heat shades a day's work from none to a full day
+var heat = []lipgloss.Style{
+	style,
+	lipgloss.NewStyle().Foreground(lipgloss.Color("22")),
+	lipgloss.NewStyle().Foreground(lipgloss.Color("28")),
+	lipgloss.NewStyle().Foreground(lipgloss.Color("34")),
+	lipgloss.NewStyle().Foreground(lipgloss.Color("46")),
+}
+
+func heatLevel(d time.Duration) int {
+	switch {
+	case d <= 0:
+		return 0
+	case d < 2*time.Hour:
+		return 1
+	case d < 4*time.Hour:
+		return 2
+	case d < 6*time.Hour:
+		return 3
+	}
+	return 4
+}
+
+// heatmap draws a year of daily work as a grid of weeks across and weekdays down
+func heatmap(year int, dd map[string]time.Duration) string {
+	jan1 := time.Date(year, 1, 1, 0, 0, 0, 0, time.Local)
+	first := startOfWeek(jan1)
+	weeks := int(time.Date(year, 12, 31, 0, 0, 0, 0, time.Local).Sub(first).Hours()/24)/7 + 1
+	// month names over the week each starts in
+	months := []rune(strings.Repeat(" ", weeks+4))
+	for m := time.January; m <= time.December; m++ {
+		col := int(time.Date(year, m, 1, 0, 0, 0, 0, time.Local).Sub(first).Hours()/24) / 7
+		copy(months[col:], []rune(time.Month(m).String()[:3]))
+	}
+	lines := []string{"    " + strings.TrimRight(string(months), " ")}
+	for wd := range 7 {
+		var b strings.Builder
+		if wd%2 == 0 {
+			b.WriteString(first.AddDate(0, 0, wd).Format("Mon")[:3] + " ")
+		} else {
+			b.WriteString("    ")
+		}
+		for w := range weeks {
+			day := first.AddDate(0, 0, w*7+wd)
+			if day.Year() != year {
+				b.WriteString(" ")
+				continue
+			}
+			lvl := heatLevel(dd[day.Format(time.DateOnly)])
+			if lvl == 0 {
+				b.WriteString(style.Render("·"))
+				continue
+			}
+			b.WriteString(heat[lvl].Render("■"))
+		}
+		lines = append(lines, b.String())
+	}
+	var key strings.Builder
+	key.WriteString("    none ")
+	for _, h := range heat[1:] {
+		key.WriteString(h.Render("■"))
+	}
+	key.WriteString(" 6h+")
+	return strings.Join(append(lines, key.String()), "\n")
+}
+
+var (
+	dayLabels   = []string{"Mo", "Tu", "We", "Th", "Fr", "Sa", "Su"}
+	monthLabels = []string{"Ja", "Fe", "Mr", "Ap", "My", "Jn", "Jl", "Au", "Se", "Oc", "No", "De"}
+)
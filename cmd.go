@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// commands can be run from the command line e.g. `clockon import toggl.csv`
+var commands = map[string]func(*logger, []string) error{
+	"import":    importCmd,
+	"leave":     leaveCmd,
+	"log":       logCmd,
+	"rename":    renameCmd,
+	"flex":      flexCmd,
+	"ics":       icsCmd,
+	"report":    reportCmd,
+	"serve":     serveCmd,
+	"stats":     statsCmd,
+	"status":    statusCmd,
+	"timesheet": timesheetCmd,
+}
+
+func command(lg *logger, args []string) error {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		names := make([]string, 0, len(commands))
+		for k := range commands {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown command %q, expecting one of: %s", args[0], strings.Join(names, ", "))
+	}
+	return cmd(lg, args[1:])
+}
+
+// logCmd records a finished stint e.g. `clockon log -activity "Top Secret Project" -duration 25m -note "wrote the plan"`
+func logCmd(lg *logger, args []string) error {
+	fs := flag.NewFlagSet("log", flag.ExitOnError)
+	activity := fs.String("activity", "", "the activity worked on (defaults to the selected activity)")
+	start := fs.String("start", "", "when the stint started (YYYY-MM-DD HH:MM), defaults to the duration before now")
+	d := fs.Duration("duration", 0, "how long the stint went for e.g. 25m")
+	rest := fs.Bool("break", false, "record a break rather than work")
+	note := fs.String("note", "", "a note on what was done")
+	fs.Parse(args)
+	if *d <= 0 {
+		return errors.New("log needs a -duration")
+	}
+	activities, sel, _, _, _, _ := lg.refresh()
+	if *activity == "" {
+		if len(activities) == 0 {
+			return errors.New("log needs an -activity")
+		}
+		*activity = activities[sel]
+	}
+	t := time.Now().Add(-*d)
+	if *start != "" {
+		var err error
+		if t, err = parseTime(*start); err != nil {
+			return err
+		}
+	}
+	typ := working
+	if *rest {
+		typ = resting
+	}
+	es := []entry{{a: *activity, typ: typ, t: t, d: *d, n: *note}}
+	if !slices.Contains(activities, *activity) {
+		es = append([]entry{{a: *activity, typ: selecting, t: t}}, es...)
+	}
+	return lg.insert(es...)
+}
+
+// renameCmd renames an activity, or merges it into another if the new name is already an activity
+func renameCmd(lg *logger, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: clockon rename OLD NEW")
+	}
+	from, to := args[0], strings.TrimSpace(args[1])
+	if to == "" || to == from {
+		return errors.New("rename needs a different new name")
+	}
+	if err := validName(to); err != nil {
+		return err
+	}
+	activities, _, _, _, _, _ := lg.refresh()
+	if !slices.Contains(activities, from) {
+		return fmt.Errorf("no activity called %q", from)
+	}
+	lg.send(entry{a: from, typ: renaming, t: time.Now(), to: to})
+	lg.flush()
+	if slices.Contains(activities, to) {
+		fmt.Printf("merged %q into %q\n", from, to)
+	} else {
+		fmt.Printf("renamed %q to %q\n", from, to)
+	}
+	return nil
+}
+
+// parseRange parses from and to dates (YYYY-MM-DD) into a half-open interval covering both days.
+// A blank from means the beginning of the log and a blank to means today.
+func parseRange(from, to string) (time.Time, time.Time, error) {
+	var start, end time.Time
+	var err error
+	if from != "" {
+		start, err = time.ParseInLocation(time.DateOnly, from, time.Local)
+		if err != nil {
+			return start, end, err
+		}
+	}
+	if to == "" {
+		end = time.Now()
+	} else {
+		end, err = time.ParseInLocation(time.DateOnly, to, time.Local)
+		if err != nil {
+			return start, end, err
+		}
+	}
+	y, m, d := end.Date()
+	end = time.Date(y, m, d+1, 0, 0, 0, 0, time.Local)
+	if !start.Before(end) {
+		return start, end, fmt.Errorf("from %s is after to %s", from, to)
+	}
+	return start, end, nil
+}
+
+// stints returns the working and resting entries within a range, optionally limited to an activity (and its sub-activities if rolled up)
+func (l *logger) stints(activity string, rollup bool, start, end time.Time) []entry {
+	var ret []entry
+	l.bidx, l.sidx = 0, 0
+	for e, err := l.next(); err == nil; e, err = l.next() {
+		if e.typ != working && e.typ != resting {
+			continue
+		}
+		if !matches(e.a, activity, rollup) {
+			continue
+		}
+		if e.t.Before(start) || !e.t.Before(end) {
+			continue
+		}
+		ret = append(ret, e)
+	}
+	return ret
+}
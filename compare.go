@@ -0,0 +1,71 @@
+package main
+
+import (
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/snabb/isoweek"
+)
+
+func sum(d [][2]time.Duration) [2]time.Duration {
+	var ret [2]time.Duration
+	for _, v := range d {
+		ret[0] += v[0]
+		ret[1] += v[1]
+	}
+	return ret
+}
+
+// a dash means there's nothing to compare against
+func compareCells(rows []table.Row, d [2]time.Duration, earlier [][2]time.Duration) []table.Row {
+	for i := range rows {
+		rows[i] = append(rows[i], "")
+	}
+	last := len(rows[0]) - 1
+	if earlier == nil {
+		for i := range 3 {
+			rows[i][last] = "-"
+		}
+		return rows
+	}
+	e := sum(earlier)
+	rows[0][last] = signed(d[0] - e[0])
+	rows[1][last] = signed(d[1] - e[1])
+	rows[2][last] = signed(d[0] + d[1] - e[0] - e[1])
+	return rows
+}
+
+func (l *logger) compareWeeks(rows []table.Row, activity string, rollup bool, d [][2]time.Duration, week [2]int) []table.Row {
+	py, pw := isoweek.StartTime(week[0], week[1], time.Local).AddDate(0, 0, -7).ISOWeek()
+	pd, _, _ := l.weekDays(activity, rollup, [2]int{py, pw})
+	rows = compareCells(rows, sum(d), pd)
+	ly, _, lyPrev := l.weekDays(activity, rollup, [2]int{week[0] - 1, week[1]})
+	if lyPrev[0] == 0 && sum(ly) == [2]time.Duration{} {
+		ly = nil // nothing logged that far back
+	}
+	return compareCells(rows, sum(d), ly)
+}
+
+func (l *logger) compareYears(rows []table.Row, activity string, rollup bool, d [][2]time.Duration, year int) []table.Row {
+	pd, _, _ := l.yearMonths(activity, rollup, year-1)
+	return compareCells(rows, sum(d), pd)
+}
+
+func earlierRange(start, end time.Time) ([2]time.Time, [2]time.Time) {
+	days := int(end.Sub(start).Hours()/24 + 0.5)
+	return [2]time.Time{start.AddDate(0, 0, -days), start}, [2]time.Time{start.AddDate(-1, 0, 0), end.AddDate(-1, 0, 0)}
+}
+
+type comparison struct {
+	title string
+	span  [2]time.Time
+	work  map[string]time.Duration
+}
+
+func newComparison(title string, span [2]time.Time, rows []reportRow, total reportRow) comparison {
+	c := comparison{title: title, span: span, work: make(map[string]time.Duration, len(rows)+1)}
+	for _, r := range append(rows, total) {
+		c.work[r.name] = r.d[0]
+	}
+	return c
+}
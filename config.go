@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var configpath string
+var configname = "clockon.json"
+
+func init() {
+	cd, _ := os.UserConfigDir()
+	configpath = filepath.Join(cd, "clockon")
+}
+
+// cfg is loaded from clockon.json in the user's config directory (e.g. ~/.config/clockon/clockon.json)
+var cfg config
+
+type config struct {
+	// Rounding policies keyed by activity. The "*" key applies to activities without their own policy.
+	Rounding map[string]rounding `json:"rounding"`
+	// Billable rates keyed by activity. The "*" key applies to activities without their own rate.
+	Rates map[string]rate `json:"rates"`
+	// Technique for trading off work and breaks: Third Time by default, or Pomodoro or custom intervals
+	Technique techniqueConfig `json:"technique"`
+	// Notifications sent when the bank runs out, a stint runs too long or the daily goal is reached
+	Notify notifyConfig `json:"notify"`
+	// Hours to work each day or week, overall or on an activity
+	Goals goals `json:"goals"`
+	// Expected hours and days off, for working out a flex-time balance
+	Schedule schedule `json:"schedule"`
+	// Weeks of daily work to show as a sparkline, 4 if not set or -1 to hide it
+	Sparkline int `json:"sparkline"`
+	// Shell commands run on events like starting work or a break, keyed by event or "*" for all of them
+	Hooks map[string]string `json:"hooks"`
+}
+
+func (c config) sparkWeeks() int {
+	if c.Sparkline == 0 {
+		return 4
+	}
+	return max(c.Sparkline, 0)
+}
+
+func loadConfig() error {
+	byt, err := os.ReadFile(filepath.Join(configpath, configname))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	return json.Unmarshal(byt, &cfg)
+}
+
+// duration reads and writes as a string like "1h30m" in the config file
+type duration time.Duration
+
+func (d duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// todayStints returns today's stints, including the one on the clock
+func (m model) todayStints() []entry {
+	now := time.Now()
+	es := m.log.day(now)
+	if s, ok := m.running(); ok {
+		es = append(es, entry{a: m.activities[m.selected], typ: s, t: now.Add(-m.stopwatch.Elapsed()), d: m.stopwatch.Elapsed()})
+	}
+	return es
+}
+
+// timelineStrip draws stints across a span of time, with work as solid blocks and breaks shaded
+func timelineStrip(es []entry, start, end time.Time, width int) string {
+	cells := make([]state, width)
+	span := end.Sub(start)
+	if span <= 0 {
+		return ""
+	}
+	for _, e := range es {
+		from := int(float64(e.t.Sub(start)) / float64(span) * float64(width))
+		to := int(float64(e.t.Add(e.d).Sub(start)) / float64(span) * float64(width))
+		for i := max(from, 0); i <= min(to, width-1); i++ {
+			if cells[i] != working { // work shows over a break sharing the cell
+				cells[i] = e.typ
+			}
+		}
+	}
+	var b strings.Builder
+	for _, c := range cells {
+		switch c {
+		case working:
+			b.WriteString(barStyle.Render("█"))
+		case resting:
+			b.WriteString(style.Render("▒"))
+		default:
+			b.WriteString(style.Render("·"))
+		}
+	}
+	return b.String()
+}
+
+// dashboardView shows today's work and breaks across all activities
+func (m model) dashboardView() string {
+	now := time.Now()
+	es := m.todayStints()
+	var b strings.Builder
+	b.WriteString(hstyle.Render(fmt.Sprintf("Today, %s:", now.Format("Monday 2 January"))) + "\n")
+	if len(es) == 0 {
+		b.WriteString(style.Render("Nothing yet") + "\n")
+		return b.String() + m.helpView()
+	}
+	first, last := es[0].t, es[len(es)-1].t.Add(es[len(es)-1].d)
+	if _, ok := m.running(); ok {
+		fmt.Fprintf(&b, "Started %s, on the clock now\n", first.Local().Format(time.Kitchen))
+	} else {
+		fmt.Fprintf(&b, "Started %s, last stopped %s\n", first.Local().Format(time.Kitchen), last.Local().Format(time.Kitchen))
+	}
+	b.WriteString(m.technique.status(m.bank) + "\n\n")
+	// totals per activity, in the order first worked on today
+	var order []string
+	totals := make(map[string][2]time.Duration)
+	for _, e := range es {
+		t, ok := totals[e.a]
+		if !ok {
+			order = append(order, e.a)
+		}
+		t[e.typ-working] += e.d
+		totals[e.a] = t
+	}
+	var sum [2]time.Duration
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Activity\tWork\tBreak\tTotal\t")
+	for _, a := range order {
+		t := totals[a]
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t\n", a, fmtDuration(t[0]), fmtDuration(t[1]), fmtDuration(t[0]+t[1]))
+		sum[0] += t[0]
+		sum[1] += t[1]
+	}
+	fmt.Fprintf(tw, "Total\t%s\t%s\t%s\t\n", fmtDuration(sum[0]), fmtDuration(sum[1]), fmtDuration(sum[0]+sum[1]))
+	tw.Flush()
+	// timeline from the first start to the last stop, on whole hours
+	start := first.Truncate(time.Hour)
+	end := last.Truncate(time.Hour).Add(time.Hour)
+	fmt.Fprintf(&b, "\n%s %s %s\n", start.Local().Format("3PM"), timelineStrip(es, start, end, 48), end.Local().Format("3PM"))
+	return b.String() + m.helpView()
+}
+
+func (m model) updateDashboard(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, m.keymap.quit):
+			if m.switching() {
+				m = m.logStint(m.statePrev, time.Now())
+			}
+			return m.switchTo(quitting), tea.Quit
+		case key.Matches(msg, m.keymap.dashboard) || msg.Type == tea.KeyEnter || msg.Type == tea.KeySpace || msg.Type == tea.KeyEsc:
+			return m.switchTo(m.statePrev), nil
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// logStint sends the running stint to the log along with any note
+func (m model) logStint(typ state, t time.Time) model {
+	m.log.send(entry{a: m.activities[m.selected], typ: typ, t: t, d: m.stopwatch.Elapsed(), n: m.note})
+	m.note = ""
+	return m
+}
+
+func (m model) noteView() string {
+	var hdr string
+	switch m.statePrev {
+	case working:
+		hdr = fmt.Sprintf("Note for this stint on %s (working for %s):", m.activities[m.selected], m.stopwatch.Elapsed())
+	case resting:
+		hdr = fmt.Sprintf("Note for this break from %s (breaking for %s):", m.activities[m.selected], m.stopwatch.Elapsed())
+	default:
+		hdr = "Note for the last stint:"
+	}
+	return fmt.Sprintf("%s\n%s\n\n%s", hdr, m.textInput.View(), style.Render("(enter) to save • (esc) to cancel"))
+}
+
+// updateNote captures a note for the running stint, or for the last one if stopped
+func (m model) updateNote(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyEsc:
+			m.textInput.Reset()
+			return m.switchTo(m.statePrev), nil
+		case tea.KeyCtrlC:
+			return m.switchTo(quitting), tea.Quit
+		case tea.KeyEnter:
+			if m.statePrev == ready {
+				m.log.annotate(m.textInput.Value())
+			} else {
+				m.note = m.textInput.Value()
+			}
+			m.textInput.Reset()
+			return m.switchTo(m.statePrev), nil
+		}
+	}
+	m.textInput, cmd = m.textInput.Update(msg)
+	return m, cmd
+}
+
+// day returns the stints that started on the given day
+func (l *logger) day(t time.Time) []entry {
+	y, mo, d := t.Date()
+	start := time.Date(y, mo, d, 0, 0, 0, 0, time.Local)
+	return l.stints("", false, start, start.AddDate(0, 0, 1))
+}
+
+func entryRows(es []entry) []table.Row {
+	ret := make([]table.Row, len(es))
+	for i, e := range es {
+		typ := "work"
+		if e.typ == resting {
+			typ = "break"
+		}
+		ret[i] = table.Row{
+			e.t.Local().Format(time.Kitchen),
+			e.t.Add(e.d).Local().Format(time.Kitchen),
+			e.a,
+			typ,
+			fmtDuration(e.d),
+			e.n,
+		}
+	}
+	return ret
+}
+
+func (m model) entriesView() string {
+	hdr := fmt.Sprintf("Entries for %s:", m.day.Format("Monday 2 January 2006"))
+	body := style.Render("No entries")
+	if len(m.entryTbl.Rows()) > 0 {
+		body = tableStyle.Render(m.entryTbl.View())
+	}
+	return fmt.Sprintf("%s\n%s\n%s", hstyle.Render(hdr), body, m.helpView())
+}
+
+func (m model) updateEntries(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, m.keymap.quit):
+			return m.switchTo(quitting), tea.Quit
+		case key.Matches(msg, m.keymap.next):
+			m.day = m.day.AddDate(0, 0, 1)
+			return m.switchTo(listing), nil
+		case key.Matches(msg, m.keymap.prev):
+			m.day = m.day.AddDate(0, 0, -1)
+			return m.switchTo(listing), nil
+		case msg.Type == tea.KeyEnter || msg.Type == tea.KeySpace || msg.Type == tea.KeyEsc:
+			return m.switchTo(ready), nil
+		}
+	}
+	m.entryTbl, cmd = m.entryTbl.Update(msg)
+	return m, cmd
+}
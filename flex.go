@@ -0,0 +1,205 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/snabb/isoweek"
+)
+
+type schedule struct {
+	Days      map[string]duration `json:"days"`      // keyed mon to sun
+	Holidays  []string            `json:"holidays"`  // YYYY-MM-DD
+	Calendars []string            `json:"calendars"` // .ics files
+	Start     string              `json:"start"`     // YYYY-MM-DD
+	Opening   duration            `json:"opening"`
+}
+
+func (s schedule) set() bool {
+	return len(s.Days) > 0 || len(s.Holidays) > 0 || len(s.Calendars) > 0 || s.Start != "" || s.Opening != 0
+}
+
+var weekdays = [7]string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}
+
+func (s schedule) week() ([7]time.Duration, error) {
+	var ret [7]time.Duration
+	if len(s.Days) == 0 {
+		for i := range 5 {
+			ret[i] = 7*time.Hour + 36*time.Minute
+		}
+	}
+	for k, v := range s.Days {
+		i := -1
+		for j, wd := range weekdays {
+			if strings.HasPrefix(strings.ToLower(k), wd) {
+				i = j
+			}
+		}
+		if i < 0 {
+			return ret, fmt.Errorf("unknown day %q in schedule, expecting mon to sun", k)
+		}
+		ret[i] = time.Duration(v)
+	}
+	return ret, nil
+}
+
+type plan struct {
+	days    [7]time.Duration
+	off     map[string]bool
+	leave   map[string]time.Duration // a whole day if zero
+	start   time.Time
+	opening time.Duration
+}
+
+func (s schedule) plan(lg *logger) (plan, error) {
+	p := plan{off: make(map[string]bool), opening: time.Duration(s.Opening)}
+	var err error
+	if p.days, err = s.week(); err != nil {
+		return p, err
+	}
+	for _, h := range s.Holidays {
+		if _, err := time.Parse(time.DateOnly, h); err != nil {
+			return p, fmt.Errorf("bad holiday %q in schedule: %v", h, err)
+		}
+		p.off[h] = true
+	}
+	for _, c := range s.Calendars {
+		f, err := os.Open(c)
+		if err != nil {
+			return p, err
+		}
+		ds, err := readICS(f)
+		f.Close()
+		if err != nil {
+			return p, fmt.Errorf("%s: %v", c, err)
+		}
+		for _, d := range ds {
+			p.off[d.day.Format(time.DateOnly)] = true
+		}
+	}
+	p.leave = leaveDays(lg.leaves(time.Time{}, time.Now().AddDate(1, 0, 0)))
+	if s.Start != "" {
+		t, err := time.ParseInLocation(time.DateOnly, s.Start, time.Local)
+		if err != nil {
+			return p, fmt.Errorf("bad start in schedule: %v", err)
+		}
+		p.start = t
+		return p, nil
+	}
+	lg.bidx, lg.sidx = 0, 0
+	for e, err := lg.next(); err == nil; e, err = lg.next() {
+		if e.typ == working || e.typ == resting {
+			p.start = startOfDay(e.t)
+			break
+		}
+	}
+	return p, nil
+}
+
+func (p plan) expected(t time.Time) time.Duration {
+	if p.off[t.Format(time.DateOnly)] {
+		return 0
+	}
+	return p.days[dayIndex(t)]
+}
+
+func (p plan) taken(t time.Time) time.Duration {
+	d, ok := p.leave[t.Format(time.DateOnly)]
+	if !ok {
+		return 0
+	}
+	if exp := p.expected(t); d == 0 || d > exp {
+		return exp
+	}
+	return d
+}
+
+type flexRow struct {
+	week     [2]int
+	expected time.Duration
+	worked   time.Duration
+	leave    time.Duration
+	balance  time.Duration
+}
+
+func (l *logger) flex(p plan, until time.Time) []flexRow {
+	if p.start.IsZero() {
+		return nil
+	}
+	var ret []flexRow
+	balance := p.opening
+	dd := daily(l.stints("", false, p.start, startOfDay(until).AddDate(0, 0, 1)))
+	y, w := p.start.ISOWeek()
+	for {
+		wk := [2]int{y, w}
+		ws := isoweek.StartTime(y, w, time.Local)
+		if ws.After(until) {
+			break
+		}
+		r := flexRow{week: wk}
+		for i := range 7 {
+			day := ws.AddDate(0, 0, i)
+			if day.Before(p.start) || day.After(until) {
+				continue
+			}
+			r.expected += p.expected(day)
+			r.worked += dd[day.Format(time.DateOnly)]
+			r.leave += p.taken(day)
+		}
+		balance += r.worked + r.leave - r.expected
+		r.balance = balance
+		ret = append(ret, r)
+		y, w = ws.AddDate(0, 0, 7).ISOWeek()
+	}
+	return ret
+}
+
+func signed(d time.Duration) string {
+	if d < 0 {
+		return "-" + fmtDuration(-d)
+	}
+	return "+" + fmtDuration(d)
+}
+
+func flexCmd(lg *logger, args []string) error {
+	fs := flag.NewFlagSet("flex", flag.ExitOnError)
+	from := fs.String("from", "", "first day to show (YYYY-MM-DD), the balance still counts from the start of the schedule")
+	to := fs.String("to", "", "last day to count (YYYY-MM-DD), defaults to today")
+	fs.Parse(args)
+	start, end, err := parseRange(*from, *to)
+	if err != nil {
+		return err
+	}
+	p, err := cfg.Schedule.plan(lg)
+	if err != nil {
+		return err
+	}
+	rows := lg.flex(p, end.AddDate(0, 0, -1))
+	return writeFlex(os.Stdout, rows, p, start)
+}
+
+func writeFlex(w io.Writer, rows []flexRow, p plan, from time.Time) error {
+	if len(rows) == 0 {
+		_, err := fmt.Fprintln(w, "Nothing logged yet")
+		return err
+	}
+	fmt.Fprintf(w, "Flex balance from %s, brought forward %s\n\n", p.start.Format(time.DateOnly), signed(p.opening))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Week\tExpected\tWorked\tLeave\t+/-\tBalance\t")
+	for _, r := range rows {
+		if !isoweek.StartTime(r.week[0], r.week[1], time.Local).AddDate(0, 0, 7).After(from) { // ends before from
+			continue
+		}
+		fmt.Fprintf(tw, "%d-W%02d\t%s\t%s\t%s\t%s\t%s\t\n", r.week[0], r.week[1], fmtDuration(r.expected), fmtDuration(r.worked), fmtDuration(r.leave), signed(r.worked+r.leave-r.expected), signed(r.balance))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\nBalance: %s\n", signed(rows[len(rows)-1].balance))
+	return err
+}
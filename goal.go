@@ -0,0 +1,325 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// a bare duration like "7h" sets the minimum
+type bounds struct {
+	Min duration `json:"min"`
+	Max duration `json:"max"`
+}
+
+func (b *bounds) UnmarshalJSON(byt []byte) error {
+	if len(byt) > 0 && byt[0] == '"' {
+		return json.Unmarshal(byt, &b.Min)
+	}
+	type plain bounds
+	return json.Unmarshal(byt, (*plain)(b))
+}
+
+func (b bounds) set() bool {
+	return b.Min > 0 || b.Max > 0
+}
+
+func (b bounds) String() string {
+	switch {
+	case b.Min > 0 && b.Max > 0:
+		return fmtDuration(time.Duration(b.Min)) + "-" + fmtDuration(time.Duration(b.Max))
+	case b.Max > 0:
+		return "max " + fmtDuration(time.Duration(b.Max))
+	}
+	return fmtDuration(time.Duration(b.Min))
+}
+
+func (b bounds) progress(d time.Duration) float64 {
+	t := time.Duration(b.Min)
+	if t == 0 {
+		t = time.Duration(b.Max)
+	}
+	return min(float64(d)/float64(t), 1)
+}
+
+func (b bounds) over(d time.Duration) bool {
+	return b.Max > 0 && d > time.Duration(b.Max)
+}
+
+func (b bounds) left(d time.Duration) string {
+	switch {
+	case b.over(d):
+		return fmtDuration(d-time.Duration(b.Max)) + " over"
+	case b.Min > 0 && d < time.Duration(b.Min):
+		return fmtDuration(time.Duration(b.Min)-d) + " to go"
+	case b.Max > 0:
+		return fmtDuration(time.Duration(b.Max)-d) + " to spare"
+	}
+	return "done"
+}
+
+func (b bounds) diff(d time.Duration) string {
+	t := time.Duration(b.Min)
+	if t == 0 {
+		t = time.Duration(b.Max)
+	}
+	return signed(d - t)
+}
+
+type goal struct {
+	Day  bounds `json:"day"`
+	Week bounds `json:"week"`
+}
+
+type goals struct {
+	Total      goal            `json:"total"`      // all activities together
+	Activities map[string]goal `json:"activities"` // activities including their sub-activities
+}
+
+func (gs goals) set() bool {
+	return gs.Total.Day.set() || gs.Total.Week.set() || len(gs.Activities) > 0
+}
+
+func (gs goals) weekly() bool {
+	if gs.Total.Week.set() {
+		return true
+	}
+	for _, g := range gs.Activities {
+		if g.Week.set() {
+			return true
+		}
+	}
+	return false
+}
+
+// the total is keyed ""
+func (gs goals) named() []string {
+	var ret []string
+	if gs.Total.Day.set() || gs.Total.Week.set() {
+		ret = append(ret, "")
+	}
+	for k := range gs.Activities {
+		ret = append(ret, k)
+	}
+	slices.Sort(ret)
+	return ret
+}
+
+func (gs goals) get(name string) goal {
+	if name == "" {
+		return gs.Total
+	}
+	return gs.Activities[name]
+}
+
+func (gs goals) goalFor(activity string) (string, bool) {
+	if _, ok := gs.Activities[activity]; ok {
+		return activity, true
+	}
+	for _, a := range ancestors(activity) {
+		if _, ok := gs.Activities[a]; ok {
+			return a, true
+		}
+	}
+	return "", false
+}
+
+func startOfWeek(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d-dayIndex(t), 0, 0, 0, 0, t.Location())
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+func worked(es []entry, names []string) map[string]time.Duration {
+	ret := make(map[string]time.Duration, len(names))
+	for _, e := range es {
+		if e.typ != working {
+			continue
+		}
+		for _, n := range names {
+			if matches(e.a, n, true) {
+				ret[n] += e.d
+			}
+		}
+	}
+	return ret
+}
+
+func (l *logger) earlier() map[string]time.Duration {
+	now := time.Now()
+	return worked(l.stints("", false, startOfWeek(now), startOfDay(now)), cfg.Goals.named())
+}
+
+func (m model) today(name string) time.Duration {
+	var ret time.Duration
+	for i, a := range m.activities {
+		if matches(a, name, true) {
+			ret += m.tally[i][0]
+		}
+	}
+	if s, ok := m.running(); ok && s == working && matches(m.activities[m.selected], name, true) {
+		ret += m.stopwatch.Elapsed()
+	}
+	return ret
+}
+
+var barStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("63"))
+
+func progressBar(frac float64, width int, over bool) string {
+	n := int(frac * float64(width))
+	str := strings.Repeat("█", n) + strings.Repeat("░", width-n)
+	if over {
+		return alertStyle.Render(str)
+	}
+	return barStyle.Render(str)
+}
+
+func goalLine(label string, b bounds, d time.Duration) string {
+	left := b.left(d)
+	if b.over(d) {
+		left = alertStyle.Render(left)
+	}
+	return fmt.Sprintf("%-18s %s %s of %s, %s", label, progressBar(b.progress(d), 20, b.over(d)), fmtDuration(d), b, left)
+}
+
+func (m model) goalsView() string {
+	names := make([]string, 0, 2)
+	if cfg.Goals.Total.Day.set() || cfg.Goals.Total.Week.set() {
+		names = append(names, "")
+	}
+	if n, ok := cfg.Goals.goalFor(m.activities[m.selected]); ok {
+		names = append(names, n)
+	}
+	lines := make([]string, 0, 4)
+	for _, n := range names {
+		g, label := cfg.Goals.get(n), n
+		if label == "" {
+			label = "All"
+		}
+		label = truncate(label, 12)
+		d := m.today(n)
+		if g.Day.set() {
+			lines = append(lines, goalLine(label+" today", g.Day, d))
+		}
+		if g.Week.set() {
+			lines = append(lines, goalLine(label+" week", g.Week, d+m.earlier[n]))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// an activity's goal covers its sub-activities so is only compared with rolled up work
+func goalCells(rows []table.Row, activity string, rollup bool, d time.Duration) []table.Row {
+	var g bounds
+	switch {
+	case activity == "":
+		g = cfg.Goals.Total.Week
+	case rollup:
+		g = cfg.Goals.Activities[activity].Week
+	}
+	for i := range rows {
+		rows[i] = append(rows[i], "", "")
+	}
+	if !g.set() {
+		return rows
+	}
+	rows[0][len(rows[0])-2] = g.String()
+	rows[0][len(rows[0])-1] = g.diff(d)
+	return rows
+}
+
+func pace(b bounds, t time.Time) time.Duration {
+	days, err := cfg.Schedule.week()
+	var done, all time.Duration
+	for i, d := range days {
+		if i <= dayIndex(t) {
+			done += d
+		}
+		all += d
+	}
+	if err != nil || all == 0 {
+		return time.Duration(b.Min) * time.Duration(dayIndex(t)+1) / 7
+	}
+	return time.Duration(float64(b.Min) * float64(done) / float64(all))
+}
+
+func statusCmd(lg *logger, args []string) error {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	fs.Parse(args)
+	now := time.Now()
+	names := cfg.Goals.named()
+	if !slices.Contains(names, "") {
+		names = append(names, "") // for the overall totals
+	}
+	es := lg.stints("", false, startOfWeek(now), now)
+	var todays []entry
+	for _, e := range es {
+		if !e.t.Before(startOfDay(now)) {
+			todays = append(todays, e)
+		}
+	}
+	if err := writeStatus(os.Stdout, cfg.Goals.named(), worked(todays, names), worked(es, names), now); err != nil {
+		return err
+	}
+	if !cfg.Schedule.set() {
+		return nil
+	}
+	p, err := cfg.Schedule.plan(lg)
+	if err != nil {
+		return err
+	}
+	if rows := lg.flex(p, now); len(rows) > 0 {
+		fmt.Printf("\nFlex balance: %s\n", signed(rows[len(rows)-1].balance))
+	}
+	return nil
+}
+
+func writeStatus(w io.Writer, names []string, day, week map[string]time.Duration, now time.Time) error {
+	fmt.Fprintf(w, "Worked %s today and %s this week\n", fmtDuration(day[""]), fmtDuration(week[""]))
+	if len(names) == 0 {
+		_, err := fmt.Fprintln(w, "No goals set")
+		return err
+	}
+	for _, n := range names {
+		g, label := cfg.Goals.get(n), n
+		if label == "" {
+			label = "All activities"
+		}
+		fmt.Fprintf(w, "\n%s\n", label)
+		if g.Day.set() {
+			fmt.Fprintf(w, "  today:     %s of %s, %s\n", fmtDuration(day[n]), g.Day, g.Day.left(day[n]))
+		}
+		if g.Week.set() {
+			fmt.Fprintf(w, "  this week: %s of %s, %s", fmtDuration(week[n]), g.Week, g.Week.left(week[n]))
+			if g.Week.Min > 0 && week[n] < time.Duration(g.Week.Min) {
+				if ahead := week[n] - pace(g.Week, now); ahead >= 0 {
+					fmt.Fprintf(w, " (%s ahead of pace)", fmtDuration(ahead))
+				} else {
+					fmt.Fprintf(w, " (%s behind pace)", fmtDuration(-ahead))
+				}
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+)
+
+func TestGoalCells(t *testing.T) {
+	defer func(c config) { cfg = c }(cfg)
+	cfg.Goals = goals{
+		Total:      goal{Week: bounds{Min: duration(30 * time.Hour)}},
+		Activities: map[string]goal{"Client A": {Week: bounds{Min: duration(10 * time.Hour)}}, "Admin": {Day: bounds{Min: duration(time.Hour)}}},
+	}
+	for _, tc := range []struct {
+		activity string
+		rollup   bool
+		want     string
+	}{
+		{"", false, "30h00m"},
+		{"Client A", true, "10h00m"},
+		{"Client A", false, ""},
+		{"Client A/Feature X", true, ""},
+		{"Admin", true, ""},
+		{"Web", true, ""},
+	} {
+		rows := goalCells([]table.Row{{"work"}, {"break"}}, tc.activity, tc.rollup, 8*time.Hour)
+		if got := rows[0][1]; got != tc.want {
+			t.Errorf("%q: goal %q, want %q", tc.activity, got, tc.want)
+		}
+		if len(rows[1]) != 3 {
+			t.Errorf("%q: break row has %d cells, want 3", tc.activity, len(rows[1]))
+		}
+	}
+}
+
+func TestPace(t *testing.T) {
+	defer func(c config) { cfg = c }(cfg)
+	week := bounds{Min: duration(32 * time.Hour)}
+	tue, sat := localTime("2024-05-07 12:00:00"), localTime("2024-05-11 12:00:00")
+	cfg.Schedule = schedule{}
+	if got := pace(week, tue); got != time.Duration(12.8*float64(time.Hour)) {
+		t.Errorf("Monday to Friday pace on Tuesday = %s, want 12h48m", got)
+	}
+	cfg.Schedule = schedule{Days: map[string]duration{"mon": duration(8 * time.Hour), "tue": duration(8 * time.Hour), "wed": duration(8 * time.Hour), "thu": duration(8 * time.Hour)}}
+	if got := pace(week, tue); got != 16*time.Hour {
+		t.Errorf("Monday to Thursday pace on Tuesday = %s, want 16h", got)
+	}
+	if got := pace(week, sat); got != 32*time.Hour {
+		t.Errorf("Monday to Thursday pace on Saturday = %s, want 32h", got)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type hooks struct {
+	cmds  map[string]string
+	stint string // to tell switching activity from coming back to the clock
+}
+
+type hookData struct {
+	Event    string     `json:"event"`
+	Activity string     `json:"activity"`
+	From     string     `json:"from"`
+	To       string     `json:"to"`
+	Time     time.Time  `json:"time"`
+	Start    *time.Time `json:"start,omitempty"`
+	Duration float64    `json:"duration,omitempty"` // seconds
+	Bank     float64    `json:"bank"`               // seconds
+}
+
+var stateNames = [...]string{"ready", "adding", "removing", "selecting", "working", "resting", "weekly", "yearly", "editing", "renaming", "archiving", "restoring", "noting", "listing", "dashboard", "timeline", "statistics", "leave", "quitting"}
+
+func (s state) String() string {
+	if int(s) < len(stateNames) {
+		return stateNames[s]
+	}
+	return "unknown"
+}
+
+func clocked(s state) bool {
+	return s == working || s == resting
+}
+
+func (m model) transition(from, prev state) (string, bool) {
+	var running bool
+	switch from {
+	case working, resting:
+		running = true
+	case noting, selecting, editing, renaming, dashboard, timeline:
+		running = clocked(prev)
+	}
+	switch m.state {
+	case working, resting:
+		if running && !clocked(from) && prev == m.state && m.activities[m.selected] == m.hooks.stint { // back to the clock from a sub-view
+			return "", false
+		}
+		if m.state == working {
+			return "work", running
+		}
+		return "break", running
+	case ready:
+		if running {
+			return "stop", true
+		}
+	case quitting:
+		return "quit", running
+	}
+	return "", false
+}
+
+// best effort, so failures are dropped
+func (m model) hook(event string, from state, ended bool) {
+	var activity string
+	if m.selected < len(m.activities) {
+		activity = m.activities[m.selected]
+	}
+	m.hookOn(activity, event, from, ended)
+}
+
+func (m model) hookOn(activity, event string, from state, ended bool) {
+	if m.hooks == nil {
+		return
+	}
+	if clocked(m.state) {
+		m.hooks.stint = activity
+	}
+	cmds := make([]string, 0, 2)
+	for _, k := range []string{event, "*"} {
+		if c, ok := m.hooks.cmds[k]; ok {
+			cmds = append(cmds, c)
+		}
+	}
+	if len(cmds) == 0 {
+		return
+	}
+	hd := hookData{Event: event, Activity: activity, From: from.String(), To: m.state.String(), Time: time.Now(), Bank: m.bank.Seconds()}
+	i := m.log.lastStint()
+	ended = ended && i >= 0
+	if ended {
+		hd.Start, hd.Duration = &m.log.session[i].t, m.log.session[i].d.Seconds()
+	}
+	env := []string{
+		"CLOCKON_EVENT=" + hd.Event,
+		"CLOCKON_ACTIVITY=" + hd.Activity,
+		"CLOCKON_FROM=" + hd.From,
+		"CLOCKON_TO=" + hd.To,
+		"CLOCKON_TIME=" + hd.Time.Format(time.RFC3339),
+		"CLOCKON_BANK=" + m.bank.Round(time.Second).String(),
+	}
+	if ended {
+		env = append(env, "CLOCKON_START="+hd.Start.Format(time.RFC3339), "CLOCKON_DURATION="+m.log.session[i].d.Round(time.Second).String())
+	}
+	byt, _ := json.Marshal(hd)
+	for _, c := range cmds {
+		if event == "quit" {
+			run(c, env, byt)
+		} else {
+			go run(c, env, byt)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/snabb/isoweek"
+)
+
+type htmlTable struct {
+	Title string
+	Head  []string
+	Rows  [][]string
+	Chart template.HTML
+}
+
+type htmlReport struct {
+	Title     string
+	Generated string
+	Compared  []string
+	Summary   htmlTable
+	Weeks     []htmlTable
+	Years     []htmlTable
+	Leave     []string
+}
+
+var htmlTmpl = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; color: #222; margin: 2em; }
+h1 { font-size: 1.4em; }
+h2 { font-size: 1.2em; margin-top: 2em; border-bottom: 1px solid #ccc; }
+h3 { font-size: 1em; }
+p.meta { color: #666; }
+table { border-collapse: collapse; margin: 0.5em 0; }
+th, td { padding: 0.2em 0.7em; text-align: right; border-bottom: 1px solid #eee; }
+th:first-child, td:first-child { text-align: left; }
+svg { display: block; margin: 0.5em 0 1.5em; }
+svg text { font-size: 11px; fill: #555; }
+.work { fill: #5f5fff; }
+.break { fill: #bbb; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="meta">Generated {{.Generated}}{{range .Compared}}<br>{{.}}{{end}}</p>
+{{define "table"}}<table>
+<tr>{{range .Head}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+{{.Chart}}{{end}}
+<h2>{{.Summary.Title}}</h2>
+{{template "table" .Summary}}
+{{with .Leave}}<h3>Leave</h3>
+<ul>{{range .}}<li>{{.}}</li>{{end}}</ul>
+{{end}}
+{{with .Weeks}}<h2>Weeks</h2>
+{{range .}}<h3>{{.Title}}</h3>
+{{template "table" .}}{{end}}{{end}}
+{{with .Years}}<h2>Months</h2>
+{{range .}}<h3>{{.Title}}</h3>
+{{template "table" .}}{{end}}{{end}}
+</body>
+</html>
+`))
+
+func columnTitles(cols []table.Column) []string {
+	ret := make([]string, len(cols))
+	for i, c := range cols {
+		ret[i] = c.Title
+	}
+	return ret
+}
+
+func fromRows(rows []table.Row) [][]string {
+	ret := make([][]string, len(rows))
+	for i, r := range rows {
+		ret[i] = r
+	}
+	return ret
+}
+
+func svgColumns(labels []string, d [][2]time.Duration) template.HTML {
+	var top time.Duration
+	for _, v := range d {
+		top = max(top, v[0], v[1])
+	}
+	if top == 0 {
+		return ""
+	}
+	const h, bar, gap, left = 120, 12, 10, 50
+	w := left + len(d)*(2*bar+gap)
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" role="img">`, w, h+20)
+	fmt.Fprintf(&b, `<text x="0" y="10">%s</text>`, fmtDuration(top))
+	for i, v := range d {
+		x := left + i*(2*bar+gap)
+		for j, cls := range []string{"work", "break"} {
+			bh := int(float64(v[j]) / float64(top) * h)
+			fmt.Fprintf(&b, `<rect class="%s" x="%d" y="%d" width="%d" height="%d"><title>%s %s</title></rect>`, cls, x+j*bar, h-bh, bar, bh, cls, fmtDuration(v[j]))
+		}
+		fmt.Fprintf(&b, `<text x="%d" y="%d">%s</text>`, x, h+15, template.HTMLEscapeString(labels[i]))
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+func svgBars(l *logger, rows []reportRow) template.HTML {
+	var top time.Duration
+	for _, r := range rows {
+		top = max(top, r.d[0])
+	}
+	if top == 0 {
+		return ""
+	}
+	const w, bh, left = 300, 16, 240
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" role="img">`, left+w+60, len(rows)*(bh+4))
+	for i, r := range rows {
+		y := i * (bh + 4)
+		bw := int(float64(r.d[0]) / float64(top) * w)
+		fmt.Fprintf(&b, `<text x="0" y="%d">%s</text>`, y+12, template.HTMLEscapeString(truncate(r.name, 36)))
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"></rect>`, left, y, bw, bh, hexColour(string(l.colour(r.name))))
+		fmt.Fprintf(&b, `<text x="%d" y="%d">%s</text>`, left+bw+4, y+12, fmtDuration(r.d[0]))
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+var ansiColours = []string{
+	"#000000", "#800000", "#008000", "#808000", "#000080", "#800080", "#008080", "#c0c0c0",
+	"#808080", "#ff0000", "#00ff00", "#ffff00", "#0000ff", "#ff00ff", "#00ffff", "#ffffff",
+}
+
+var hexRe = regexp.MustCompile(`^#[0-9a-fA-F]{3}([0-9a-fA-F]{3})?$`)
+
+// hex or one of the 256 ANSI colours
+func hexColour(c string) string {
+	if hexRe.MatchString(c) {
+		return c
+	}
+	n, err := strconv.Atoi(c)
+	switch {
+	case err != nil || n < 0 || n > 255:
+		return "#5f5fff"
+	case n < 16:
+		return ansiColours[n]
+	case n >= 232:
+		g := 8 + (n-232)*10
+		return fmt.Sprintf("#%02x%02x%02x", g, g, g)
+	}
+	levels := []int{0, 95, 135, 175, 215, 255}
+	n -= 16
+	return fmt.Sprintf("#%02x%02x%02x", levels[n/36], levels[n/6%6], levels[n%6])
+}
+
+func writeHTML(w io.Writer, l *logger, activity string, rollup bool, rows []reportRow, total reportRow, start, end time.Time, cmps ...comparison) error {
+	name := activity
+	if name == "" {
+		name = "all activities"
+	}
+	rep := htmlReport{
+		Title:     fmt.Sprintf("Report for %s, %s", name, fmtRange([2]time.Time{start, end})),
+		Generated: time.Now().Format("Mon 2 Jan 2006 3:04PM"),
+	}
+	for _, c := range cmps {
+		rep.Compared = append(rep.Compared, c.title+": "+fmtRange(c.span))
+	}
+	head, body := reportTable(rows, total, cmps)
+	rep.Summary = htmlTable{Title: "Activities", Head: head, Rows: body, Chart: svgBars(l, rows)}
+	for _, lt := range leaveTotals(l.leaves(start, end)) {
+		rep.Leave = append(rep.Leave, lt.kind+": "+fmtLeave(lt.days, lt.d))
+	}
+	if start.IsZero() {
+		es := l.stints(activity, rollup, start, end)
+		if len(es) == 0 {
+			return htmlTmpl.Execute(w, rep)
+		}
+		start = startOfDay(es[0].t.Local())
+	}
+	for d := startOfWeek(start); d.Before(end); d = d.AddDate(0, 0, 7) {
+		yr, wk := d.ISOWeek()
+		rs, sums, _, _ := l.weeks(activity, rollup, [2]int{yr, wk})
+		if sum(sums) == [2]time.Duration{} {
+			continue
+		}
+		rep.Weeks = append(rep.Weeks, htmlTable{
+			Title: fmt.Sprintf("Week %d of %d, from %s", wk, yr, isoweek.StartTime(yr, wk, time.Local).Format("2 Jan")),
+			Head:  columnTitles(weekColumns()),
+			Rows:  fromRows(rs),
+			Chart: svgColumns(dayLabels, sums),
+		})
+	}
+	for yr := start.Year(); yr <= end.AddDate(0, 0, -1).Year(); yr++ {
+		rs, sums, _, _ := l.years(activity, rollup, yr)
+		if sum(sums) == [2]time.Duration{} {
+			continue
+		}
+		rep.Years = append(rep.Years, htmlTable{
+			Title: strconv.Itoa(yr),
+			Head:  columnTitles(yearColumns()),
+			Rows:  fromRows(rs),
+			Chart: svgColumns(monthLabels, sums),
+		})
+	}
+	return htmlTmpl.Execute(w, rep)
+}
@@ -0,0 +1,18 @@
+package main
+
+import "testing"
+
+func TestHexColour(t *testing.T) {
+	for in, want := range map[string]string{
+		"#FF5F87":                  "#FF5F87",
+		"#abc":                     "#abc",
+		"9":                        "#ff0000",
+		"205":                      "#ff5faf",
+		`#000"><script>x</script>`: "#5f5fff",
+		"#12345":                   "#5f5fff",
+	} {
+		if got := hexColour(in); got != want {
+			t.Errorf("hexColour(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+func icsCmd(lg *logger, args []string) error {
+	fs := flag.NewFlagSet("ics", flag.ExitOnError)
+	from := fs.String("from", "", "first day to export (YYYY-MM-DD), defaults to the start of the log")
+	to := fs.String("to", "", "last day to export (YYYY-MM-DD), defaults to today")
+	activity := fs.String("activity", "", "only export this activity")
+	rollup := fs.Bool("rollup", false, "include the sub-activities of the activity")
+	merge := fs.Bool("merge", false, "merge contiguous work and break stints on an activity into single events")
+	out := fs.String("o", "", "file to write (defaults to stdout)")
+	fs.Parse(args)
+	start, end, err := parseRange(*from, *to)
+	if err != nil {
+		return err
+	}
+	es := lg.stints(*activity, *rollup, start, end)
+	if *merge {
+		es = mergeStints(es)
+	}
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return writeICS(w, es, *merge)
+}
+
+// mergeStints joins stints on the same activity that start within a minute of the previous one ending.
+// Merged entries have typ selecting.
+func mergeStints(es []entry) []entry {
+	ret := make([]entry, 0, len(es))
+	for _, e := range es {
+		if len(ret) > 0 {
+			last := &ret[len(ret)-1]
+			gap := e.t.Sub(last.t.Add(last.d))
+			if last.a == e.a && gap >= 0 && gap <= time.Minute {
+				last.d = e.t.Add(e.d).Sub(last.t)
+				last.typ = selecting
+				if e.n != "" {
+					if last.n != "" {
+						last.n += "; "
+					}
+					last.n += e.n
+				}
+				continue
+			}
+		}
+		ret = append(ret, e)
+	}
+	return ret
+}
+
+const icsTime = "20060102T150405Z"
+
+func icsEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// icsFold splits content lines longer than 75 octets
+func icsFold(line string) string {
+	var b strings.Builder
+	for len(line) > 75 {
+		i := 75
+		for i > 0 && !utf8.RuneStart(line[i]) {
+			i--
+		}
+		b.WriteString(line[:i] + "\r\n ")
+		line = line[i:]
+	}
+	b.WriteString(line + "\r\n")
+	return b.String()
+}
+
+func writeICS(w io.Writer, es []entry, merged bool) error {
+	var b strings.Builder
+	b.WriteString("BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//clockon//clockon//EN\r\nCALSCALE:GREGORIAN\r\n")
+	now := time.Now().UTC().Format(icsTime)
+	for _, e := range es {
+		summary := e.a
+		switch {
+		case e.typ == working && !merged:
+			summary += " (work)"
+		case e.typ == resting:
+			summary += " (break)"
+		}
+		h := fnv.New32a()
+		h.Write([]byte(e.a))
+		var desc string
+		if e.n != "" {
+			desc = icsFold("DESCRIPTION:" + icsEscape(e.n))
+		}
+		fmt.Fprintf(&b, "BEGIN:VEVENT\r\nUID:%d-%d-%08x@clockon\r\nDTSTAMP:%s\r\nDTSTART:%s\r\nDTEND:%s\r\n%s%sEND:VEVENT\r\n",
+			e.t.Unix(), e.typ, h.Sum32(), now,
+			e.t.UTC().Format(icsTime),
+			e.t.Add(e.d).UTC().Format(icsTime),
+			icsFold("SUMMARY:"+icsEscape(summary)),
+			desc,
+		)
+	}
+	b.WriteString("END:VCALENDAR\r\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// icsDay is a day covered by an event in an iCalendar file
+type icsDay struct {
+	day     time.Time
+	summary string
+}
+
+// readICS lists the days covered by the events in an iCalendar file, such as a calendar of public holidays.
+// Events with times are taken to cover the days they start on.
+func readICS(r io.Reader) ([]icsDay, error) {
+	byt, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	// unfold continuation lines
+	lines := strings.Split(strings.ReplaceAll(strings.ReplaceAll(string(byt), "\r\n", "\n"), "\n ", ""), "\n")
+	var ret []icsDay
+	var in bool
+	var start, end time.Time
+	var summary string
+	for _, line := range lines {
+		name, val, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		name, _, _ = strings.Cut(name, ";")
+		switch name {
+		case "BEGIN":
+			if val == "VEVENT" {
+				in, start, end, summary = true, time.Time{}, time.Time{}, ""
+			}
+		case "DTSTART", "DTEND":
+			if !in || len(val) < 8 {
+				continue
+			}
+			t, err := time.ParseInLocation("20060102", val[:8], time.Local)
+			if err != nil {
+				return nil, fmt.Errorf("bad date in %s: %s", name, val)
+			}
+			if name == "DTSTART" {
+				start = t
+			} else if len(val) == 8 {
+				end = t // all day events end the day after
+			} else {
+				end = t.AddDate(0, 0, 1)
+			}
+		case "SUMMARY":
+			summary = strings.NewReplacer(`\,`, ",", `\;`, ";", `\n`, " ", `\\`, `\`).Replace(val)
+		case "END":
+			if val != "VEVENT" || !in {
+				continue
+			}
+			in = false
+			if start.IsZero() {
+				continue
+			}
+			if !end.After(start) {
+				end = start.AddDate(0, 0, 1)
+			}
+			for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+				ret = append(ret, icsDay{day: d, summary: summary})
+			}
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// importers convert the data files of other time trackers into working and resting entries
+var importers = map[string]func(io.Reader) ([]entry, error){
+	"toggl":       importToggl,
+	"timewarrior": importTimewarrior,
+	"csv":         importCSV,
+	"ics":         importICS,
+}
+
+func importCmd(lg *logger, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	format := fs.String("format", "", "format of the files to import: toggl, timewarrior, csv or ics (guessed if not given)")
+	dry := fs.Bool("dry-run", false, "preview the entries that would be imported without changing the log")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return errors.New("import needs at least one file")
+	}
+	var imported []entry
+	for _, path := range fs.Args() {
+		es, err := importFile(path, *format)
+		if err != nil {
+			return fmt.Errorf("importing %s: %v", path, err)
+		}
+		imported = append(imported, es...)
+	}
+	existing := lg.all()
+	add, dupes := dedupe(existing, imported)
+	if *dry {
+		for _, e := range add {
+			fmt.Println(e.preview())
+		}
+		fmt.Printf("would import %d entries (%d duplicates skipped)\n", len(add), dupes)
+		return nil
+	}
+	if len(add) == 0 {
+		fmt.Printf("nothing to import (%d duplicates skipped)\n", dupes)
+		return nil
+	}
+	if err := lg.insert(add...); err != nil {
+		return err
+	}
+	fmt.Printf("imported %d entries (%d duplicates skipped)\n", len(add), dupes)
+	return nil
+}
+
+func importFile(path, format string) ([]entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if format == "" {
+		format, err = guessFormat(f, path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	fn, ok := importers[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+	return fn(f)
+}
+
+func guessFormat(f *os.File, path string) (string, error) {
+	switch filepath.Ext(path) {
+	case ".data":
+		return "timewarrior", nil
+	case ".ics":
+		return "ics", nil
+	}
+	hdr, err := csv.NewReader(f).Read()
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	if _, ok := columns(hdr)["start date"]; ok {
+		return "toggl", nil
+	}
+	return "csv", nil
+}
+
+// preview renders an entry for a dry run
+func (e entry) preview() string {
+	var typ string
+	switch e.typ {
+	case selecting:
+		return fmt.Sprintf("%s  add    %s", e.t.Format(time.DateTime), e.a)
+	case working:
+		typ = "work"
+	case resting:
+		typ = "break"
+	case leave:
+		if e.d == 0 {
+			return fmt.Sprintf("%s  leave  all day %s", e.t.Format(time.DateTime), e.a)
+		}
+		typ = "leave"
+	}
+	return fmt.Sprintf("%s  %-5s  %s %s", e.t.Format(time.DateTime), typ, fmtDuration(e.d), e.a)
+}
+
+func entryKey(e entry) string {
+	return fmt.Sprintf("%s|%d|%d|%d", e.a, e.typ, e.t.Unix(), e.d/time.Second)
+}
+
+// dedupe drops imported entries already in the log (or repeated in the import) and
+// prepends a selecting entry for any new activities. Imported names are carried through
+// later renames, and activities since deleted are selected again so their stints show.
+func dedupe(existing, imported []entry) ([]entry, int) {
+	seen := make(map[string]struct{})
+	known := make(map[string]struct{})
+	live := make(map[string]struct{})
+	var renames []entry
+	for _, e := range existing {
+		if e.typ == working || e.typ == resting || e.typ == leave {
+			seen[entryKey(e)] = struct{}{}
+		}
+		known[e.a] = struct{}{}
+		switch e.typ {
+		case renaming:
+			renames = append(renames, e)
+			fallthrough
+		case removing:
+			delete(live, e.a)
+		case leave:
+		default:
+			live[e.a] = struct{}{}
+		}
+	}
+	sort.SliceStable(imported, func(i, j int) bool { return imported[i].t.Before(imported[j].t) })
+	ret := make([]entry, 0, len(imported))
+	var dupes int
+	var revived []entry
+	for _, e := range imported {
+		for _, r := range renames {
+			if e.a == r.a {
+				e.a = r.to
+			}
+		}
+		e.t = e.t.Round(time.Second)
+		e.d = e.d.Round(time.Second)
+		k := entryKey(e)
+		if _, ok := seen[k]; ok {
+			dupes++
+			continue
+		}
+		seen[k] = struct{}{}
+		if e.typ != leave {
+			if _, ok := known[e.a]; !ok {
+				known[e.a] = struct{}{}
+				live[e.a] = struct{}{}
+				ret = append(ret, entry{a: e.a, typ: selecting, t: e.t})
+			} else if _, ok := live[e.a]; !ok {
+				live[e.a] = struct{}{}
+				revived = append(revived, entry{a: e.a, typ: selecting, t: time.Now().Round(time.Second)})
+			}
+		}
+		ret = append(ret, e)
+	}
+	return append(ret, revived...), dupes
+}
+
+func columns(hdr []string) map[string]int {
+	ret := make(map[string]int, len(hdr))
+	for i, v := range hdr {
+		ret[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(v, "\ufeff")))] = i
+	}
+	return ret
+}
+
+func stint(a string, typ state, start, end time.Time) (entry, error) {
+	if a == "" {
+		return entry{}, errors.New("missing activity")
+	}
+	if err := validName(a); err != nil {
+		return entry{}, err
+	}
+	if !end.After(start) {
+		return entry{}, fmt.Errorf("%s: end %s is not after start %s", a, end.Format(time.DateTime), start.Format(time.DateTime))
+	}
+	return entry{a: a, typ: typ, t: start, d: end.Sub(start)}, nil
+}
+
+// Toggl Track's detailed CSV export
+func importToggl(r io.Reader) ([]entry, error) {
+	rdr := csv.NewReader(r)
+	hdr, err := rdr.Read()
+	if err != nil {
+		return nil, err
+	}
+	cols := columns(hdr)
+	for _, k := range []string{"start date", "start time", "end date", "end time"} {
+		if _, ok := cols[k]; !ok {
+			return nil, fmt.Errorf("missing %q column", k)
+		}
+	}
+	field := func(rec []string, k string) string {
+		if i, ok := cols[k]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+	var ret []entry
+	for {
+		rec, err := rdr.Read()
+		if err == io.EOF {
+			return ret, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, err := time.ParseInLocation(time.DateTime, field(rec, "start date")+" "+field(rec, "start time"), time.Local)
+		if err != nil {
+			return nil, err
+		}
+		end, err := time.ParseInLocation(time.DateTime, field(rec, "end date")+" "+field(rec, "end time"), time.Local)
+		if err != nil {
+			return nil, err
+		}
+		a, note := field(rec, "project"), field(rec, "description")
+		if a == "" {
+			a, note = note, ""
+		}
+		e, err := stint(a, working, start, end)
+		if err != nil {
+			return nil, err
+		}
+		e.n = note
+		ret = append(ret, e)
+	}
+}
+
+// timewarrior data files e.g. ~/.timewarrior/data/2024-01.data
+func importTimewarrior(r io.Reader) ([]entry, error) {
+	var ret []entry
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "inc ") {
+			continue
+		}
+		interval, tags, _ := strings.Cut(strings.TrimPrefix(line, "inc "), " # ")
+		times := strings.Fields(interval)
+		if len(times) != 3 || times[1] != "-" {
+			continue // an open interval is still being tracked
+		}
+		start, err := time.Parse("20060102T150405Z", times[0])
+		if err != nil {
+			return nil, err
+		}
+		end, err := time.Parse("20060102T150405Z", times[2])
+		if err != nil {
+			return nil, err
+		}
+		a := strings.Join(splitTags(tags), " ")
+		if a == "" {
+			a = "untagged"
+		}
+		e, err := stint(a, working, start.Local(), end.Local())
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, e)
+	}
+	return ret, nil
+}
+
+func splitTags(s string) []string {
+	var ret []string
+	var quoted bool
+	var tag strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ' ' && !quoted:
+			if tag.Len() > 0 {
+				ret = append(ret, tag.String())
+				tag.Reset()
+			}
+		default:
+			tag.WriteRune(r)
+		}
+	}
+	if tag.Len() > 0 {
+		ret = append(ret, tag.String())
+	}
+	return ret
+}
+
+var csvLayouts = []string{time.RFC3339, time.DateTime, "2006-01-02 15:04", "2006-01-02T15:04:05", "2006-01-02T15:04"}
+
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, l := range csvLayouts {
+		if t, err := time.ParseInLocation(l, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("can't parse time %q", s)
+}
+
+// generic CSV with start, end and activity columns, and optional type (work or break) and note columns
+func importCSV(r io.Reader) ([]entry, error) {
+	rdr := csv.NewReader(r)
+	hdr, err := rdr.Read()
+	if err != nil {
+		return nil, err
+	}
+	cols := columns(hdr)
+	for _, k := range []string{"start", "end", "activity"} {
+		if _, ok := cols[k]; !ok {
+			return nil, fmt.Errorf("missing %q column", k)
+		}
+	}
+	typIdx, hasTyp := cols["type"]
+	noteIdx, hasNote := cols["note"]
+	var ret []entry
+	for {
+		rec, err := rdr.Read()
+		if err == io.EOF {
+			return ret, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, err := parseTime(rec[cols["start"]])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseTime(rec[cols["end"]])
+		if err != nil {
+			return nil, err
+		}
+		typ := working
+		if hasTyp {
+			switch strings.ToLower(strings.TrimSpace(rec[typIdx])) {
+			case "b", "break", "rest", "resting":
+				typ = resting
+			}
+		}
+		e, err := stint(strings.TrimSpace(rec[cols["activity"]]), typ, start, end)
+		if err != nil {
+			return nil, err
+		}
+		if hasNote {
+			e.n = strings.TrimSpace(rec[noteIdx])
+		}
+		ret = append(ret, e)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func localTime(s string) time.Time {
+	t, err := time.ParseInLocation(time.DateTime, s, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestImporters(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		in   string
+		want []entry
+	}{
+		{
+			name: "toggl",
+			in: "\ufeffUser,Project,Description,Start date,Start time,End date,End time\n" +
+				"me,Web,fixing bugs,2024-05-02,09:00:00,2024-05-02,10:30:00\n" +
+				"me,,Admin,2024-05-02,11:00:00,2024-05-02,11:15:00\n",
+			want: []entry{
+				{a: "Web", typ: working, t: localTime("2024-05-02 09:00:00"), d: 90 * time.Minute, n: "fixing bugs"},
+				{a: "Admin", typ: working, t: localTime("2024-05-02 11:00:00"), d: 15 * time.Minute},
+			},
+		},
+		{
+			name: "timewarrior",
+			in: "inc 20240502T090000Z - 20240502T100000Z # Web \"code review\"\n" +
+				"inc 20240502T110000Z - 20240502T113000Z\n" +
+				"inc 20240502T120000Z # still going\n",
+			want: []entry{
+				{a: "Web code review", typ: working, t: time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC).Local(), d: time.Hour},
+				{a: "untagged", typ: working, t: time.Date(2024, 5, 2, 11, 0, 0, 0, time.UTC).Local(), d: 30 * time.Minute},
+			},
+		},
+		{
+			name: "csv",
+			in: "start,end,activity,type,note\n" +
+				"2024-05-02 09:00,2024-05-02 09:25,Web,work,first\n" +
+				"2024-05-02 09:25,2024-05-02 09:30,Web,break,\n",
+			want: []entry{
+				{a: "Web", typ: working, t: localTime("2024-05-02 09:00:00"), d: 25 * time.Minute, n: "first"},
+				{a: "Web", typ: resting, t: localTime("2024-05-02 09:25:00"), d: 5 * time.Minute},
+			},
+		},
+		{
+			name: "ics",
+			in: "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20241225\r\nDTEND;VALUE=DATE:20241227\r\n" +
+				"SUMMARY:Christmas\\, Boxing\r\n  Day\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+			want: []entry{
+				{a: "Public holiday", typ: leave, t: localTime("2024-12-25 00:00:00"), n: "Christmas, Boxing Day"},
+				{a: "Public holiday", typ: leave, t: localTime("2024-12-26 00:00:00"), n: "Christmas, Boxing Day"},
+			},
+		},
+	} {
+		got, err := importers[tc.name](strings.NewReader(tc.in))
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if len(got) != len(tc.want) {
+			t.Errorf("%s: got %d entries, want %d: %+v", tc.name, len(got), len(tc.want), got)
+			continue
+		}
+		for i, e := range got {
+			if !e.t.Equal(tc.want[i].t) {
+				t.Errorf("%s: entry %d starts %v, want %v", tc.name, i, e.t, tc.want[i].t)
+			}
+			e.t = tc.want[i].t
+			if e != tc.want[i] {
+				t.Errorf("%s: entry %d = %+v, want %+v", tc.name, i, e, tc.want[i])
+			}
+		}
+	}
+}
+
+func TestImportMultilineName(t *testing.T) {
+	in := "start,end,activity\n2024-05-02 09:00,2024-05-02 09:30,\"Web\nw bogus\"\n"
+	if _, err := importCSV(strings.NewReader(in)); err == nil {
+		t.Error("expected an activity spanning lines to be rejected")
+	}
+}
+
+func TestImportRenamedAndDeleted(t *testing.T) {
+	defer func(p string) { logpath = p }(logpath)
+	logpath = t.TempDir()
+	log := "Old\nc 2024-05-01T09:00:00Z\nOld\nw 2024-05-01T09:00:00Z 1h0m0s\n" +
+		"Gone\nc 2024-05-01T11:00:00Z\nGone\nw 2024-05-01T11:00:00Z 1h0m0s\n" +
+		"Old\nr 2024-05-03T09:00:00Z New\nGone\nd 2024-05-03T10:00:00Z\n"
+	if err := os.WriteFile(filepath.Join(logpath, logname), []byte(log), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lg := testLogger()
+	lg.bread = false
+	add, _ := dedupe(lg.all(), []entry{
+		{a: "Old", typ: working, t: localTime("2024-05-04 09:00:00"), d: time.Hour},
+		{a: "Gone", typ: working, t: localTime("2024-05-02 11:00:00"), d: time.Hour},
+	})
+	if err := lg.insert(add...); err != nil {
+		t.Fatal(err)
+	}
+	lg = testLogger()
+	lg.bread = false
+	activities, _, _, _, _, _ := lg.refresh()
+	for _, a := range []string{"New", "Gone"} {
+		if !slices.Contains(activities, a) {
+			t.Errorf("activities %v missing %s", activities, a)
+		}
+		if es := lg.stints(a, false, localTime("2024-04-30 00:00:00"), localTime("2024-05-05 00:00:00")); len(es) != 2 {
+			t.Errorf("%s has %d stints, want 2", a, len(es))
+		}
+	}
+}
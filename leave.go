@@ -0,0 +1,179 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/snabb/isoweek"
+)
+
+// Leave entries record days off, such as annual leave, sick days and public holidays.
+// They're logged against the kind of leave in place of an activity, from the start of the day,
+// and are for the whole day unless they have a duration.
+
+// leaves returns the leave entries within a range
+func (l *logger) leaves(start, end time.Time) []entry {
+	var ret []entry
+	l.bidx, l.sidx = 0, 0
+	for e, err := l.next(); err == nil; e, err = l.next() {
+		if e.typ != leave || e.t.Before(start) || !e.t.Before(end) {
+			continue
+		}
+		ret = append(ret, e)
+	}
+	return ret
+}
+
+// leaveDays sums leave by date, with a whole day as zero
+func leaveDays(es []entry) map[string]time.Duration {
+	ret := make(map[string]time.Duration)
+	for _, e := range es {
+		k := e.t.Local().Format(time.DateOnly)
+		if d, ok := ret[k]; ok && d == 0 {
+			continue
+		}
+		if e.d == 0 {
+			ret[k] = 0
+			continue
+		}
+		ret[k] += e.d
+	}
+	return ret
+}
+
+// fmtLeave describes an amount of leave in whole days and hours
+func fmtLeave(days int, d time.Duration) string {
+	var parts []string
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+	}
+	if d > 0 || days == 0 {
+		parts = append(parts, fmtDuration(d))
+	}
+	return strings.Join(parts, "+")
+}
+
+// leaveRow adds a row for any leave in an ISO week to the weekly report
+func (l *logger) leaveRow(rows []table.Row, week [2]int) []table.Row {
+	start := isoweek.StartTime(week[0], week[1], time.Local)
+	ld := leaveDays(l.leaves(start, start.AddDate(0, 0, 7)))
+	if len(ld) == 0 {
+		return rows
+	}
+	row := make(table.Row, len(rows[0]))
+	row[0] = "leave"
+	var days int
+	var hrs time.Duration
+	for i := range 7 {
+		d, ok := ld[start.AddDate(0, 0, i).Format(time.DateOnly)]
+		switch {
+		case !ok:
+		case d == 0:
+			row[i+1] = "day"
+			days++
+		default:
+			row[i+1] = fmtDuration(d)
+			hrs += d
+		}
+	}
+	row[8] = fmtLeave(days, hrs)
+	return append(rows, row)
+}
+
+type leaveTotal struct {
+	kind string
+	days int
+	d    time.Duration // partial days
+}
+
+// leaveTotals sums leave by kind
+func leaveTotals(es []entry) []leaveTotal {
+	var ret []leaveTotal
+	idx := make(map[string]int)
+	for _, e := range es {
+		i, ok := idx[e.a]
+		if !ok {
+			i = len(ret)
+			idx[e.a] = i
+			ret = append(ret, leaveTotal{kind: e.a})
+		}
+		if e.d == 0 {
+			ret[i].days++
+		} else {
+			ret[i].d += e.d
+		}
+	}
+	return ret
+}
+
+func writeLeave(w io.Writer, es []entry) error {
+	if len(es) == 0 {
+		return nil
+	}
+	fmt.Fprintln(w, "\nLeave")
+	for _, lt := range leaveTotals(es) {
+		if _, err := fmt.Fprintf(w, "  %s: %s\n", lt.kind, fmtLeave(lt.days, lt.d)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// leaveCmd records leave e.g. `clockon leave -kind "Sick leave" 2024-05-02` or for part of a day with -duration.
+// Given a range, days without any work expected in the schedule are skipped.
+func leaveCmd(lg *logger, args []string) error {
+	fs := flag.NewFlagSet("leave", flag.ExitOnError)
+	kind := fs.String("kind", "Annual leave", "the kind of leave e.g. Sick leave")
+	dur := fs.Duration("duration", 0, "how much of the day was taken as leave e.g. 3h30m (defaults to the whole day)")
+	note := fs.String("note", "", "a note on the leave")
+	fs.Parse(args)
+	if fs.NArg() == 0 || fs.NArg() > 2 {
+		return errors.New("leave needs a day (YYYY-MM-DD), or the first and last days of a range")
+	}
+	start, end, err := parseRange(fs.Arg(0), fs.Arg(fs.NArg()-1))
+	if err != nil {
+		return err
+	}
+	if *dur < 0 || *dur > 24*time.Hour {
+		return fmt.Errorf("bad duration %s", *dur)
+	}
+	var p plan
+	if fs.NArg() > 1 {
+		if p, err = cfg.Schedule.plan(lg); err != nil {
+			return err
+		}
+	}
+	var es []entry
+	for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+		if fs.NArg() > 1 && p.expected(d) == 0 {
+			continue
+		}
+		es = append(es, entry{a: *kind, typ: leave, t: d, d: *dur, n: *note})
+	}
+	if len(es) == 0 {
+		return errors.New("no working days in that range")
+	}
+	if err := lg.insert(es...); err != nil {
+		return err
+	}
+	fmt.Printf("recorded %d days of %s\n", len(es), *kind)
+	return nil
+}
+
+// importICS reads a list of holidays from an iCalendar file as days of public holiday leave, noting their names
+func importICS(r io.Reader) ([]entry, error) {
+	ds, err := readICS(r)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]entry, len(ds))
+	for i, d := range ds {
+		ret[i] = entry{a: "Public holiday", typ: leave, t: d.day, n: d.summary}
+	}
+	return ret, nil
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/charmbracelet/bubbles/table"
+	"github.com/snabb/isoweek"
 )
 
 var logpath string
@@ -27,6 +28,8 @@ type entry struct {
 	typ state
 	t   time.Time
 	d   time.Duration
+	to  string // the new name when renaming
+	n   string // an optional note on a stint
 }
 
 func (e entry) String() string {
@@ -35,45 +38,185 @@ func (e entry) String() string {
 		return fmt.Sprintf("%s\nc %s\n", e.a, e.t.Format(time.RFC3339))
 	case removing:
 		return fmt.Sprintf("%s\nd %s\n", e.a, e.t.Format(time.RFC3339))
+	case renaming:
+		return fmt.Sprintf("%s\nr %s %s\n", e.a, e.t.Format(time.RFC3339), e.to)
+	case archiving:
+		return fmt.Sprintf("%s\nx %s\n", e.a, e.t.Format(time.RFC3339))
+	case restoring:
+		return fmt.Sprintf("%s\nu %s\n", e.a, e.t.Format(time.RFC3339))
 	case working:
-		return fmt.Sprintf("%s\nw %s %s\n", e.a, e.t.Format(time.RFC3339), e.d.Round(time.Second))
+		return fmt.Sprintf("%s\nw %s %s%s\n", e.a, e.t.Format(time.RFC3339), e.d.Round(time.Second), e.note())
 	case resting:
-		return fmt.Sprintf("%s\nb %s %s\n", e.a, e.t.Format(time.RFC3339), e.d.Round(time.Second))
+		return fmt.Sprintf("%s\nb %s %s%s\n", e.a, e.t.Format(time.RFC3339), e.d.Round(time.Second), e.note())
+	case leave:
+		return fmt.Sprintf("%s\nl %s %s%s\n", e.a, e.t.Format(time.RFC3339), e.d.Round(time.Second), e.note())
 	}
 	return ""
 }
 
+// validName checks an activity name can be written to the log, which has a line per name
+func validName(a string) error {
+	if strings.ContainsAny(a, "\r\n") {
+		return fmt.Errorf("activity name %q can't span lines", a)
+	}
+	return nil
+}
+
+func (e entry) valid() error {
+	if err := validName(e.a); err != nil {
+		return err
+	}
+	return validName(e.to)
+}
+
+func (e entry) note() string {
+	if e.n == "" {
+		return ""
+	}
+	return " " + strings.Join(strings.Fields(e.n), " ") // notes must fit on the line
+}
+
 type logger struct {
+	meta     map[string]meta
+	archived map[string]bool      // set by refresh
+	used     map[string]time.Time // set by refresh
 	sidx     int
 	bidx     int
 	bread    bool
+	bad      error // why the log couldn't be read in full, so it isn't rewritten without the entries it's missing
 	session  []entry
 	buffered []entry
 }
 
 func newlogger() (*logger, error) {
 	os.MkdirAll(logpath, os.ModeDir)
+	md, err := loadMeta()
+	if err != nil {
+		return nil, err
+	}
 	return &logger{
-		session: make([]entry, 0, 50),
+		meta:     md,
+		archived: make(map[string]bool),
+		used:     make(map[string]time.Time),
+		session:  make([]entry, 0, 50),
 	}, nil
 }
 
 func (l *logger) send(e entry) {
+	if e.typ == renaming {
+		l.read()
+		rename(l.buffered, e.a, e.to)
+		rename(l.session, e.a, e.to)
+		if md, ok := l.meta[e.a]; ok {
+			if _, ok := l.meta[e.to]; !ok { // when merging keep the details of the activity merged into
+				l.meta[e.to] = md
+			}
+			delete(l.meta, e.a)
+			l.saveMeta()
+		}
+	}
 	if e.d > 0 {
 		e.t = e.t.Add(e.d * -1)
 	}
 	l.session = append(l.session, e)
 }
 
-func (l *logger) flush() {
+func (l *logger) flush() error {
 	f, err := os.OpenFile(filepath.Join(logpath, logname), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
-		return
+		return err
 	}
 	for _, e := range l.session {
-		f.WriteString(e.String())
+		if _, err = f.WriteString(e.String()); err != nil {
+			break
+		}
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// all returns every entry in the log, including those sent this session
+func (l *logger) all() []entry {
+	l.bidx, l.sidx = 0, 0
+	ret := make([]entry, 0, len(l.buffered)+len(l.session))
+	for e, err := l.next(); err == nil; e, err = l.next() {
+		ret = append(ret, e)
+	}
+	return ret
+}
+
+// replaceLog writes a new log to a temporary file then moves it into place, so a failure part way leaves the old log as it was
+func replaceLog(write func(w io.Writer) error) error {
+	f, err := os.CreateTemp(logpath, logname+".*")
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	if err == nil {
+		err = f.Chmod(0644)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(logpath, logname))
+}
+
+// rewrite replaces the log file with the given entries. It won't if the log couldn't be read in full.
+func (l *logger) rewrite(es []entry) error {
+	if l.read(); l.bad != nil {
+		return fmt.Errorf("not rewriting the log as %v", l.bad)
+	}
+	err := replaceLog(func(w io.Writer) error {
+		for _, e := range es {
+			if _, err := io.WriteString(w, e.String()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	l.bread = true
+	l.buffered = es
+	l.session = l.session[:0]
+	return nil
+}
+
+// insert adds entries to the log in time order, for entries that may be back-dated.
+// Unlike send, stints are expected to be timed from their start.
+func (l *logger) insert(es ...entry) error {
+	for _, e := range es {
+		if err := e.valid(); err != nil {
+			return err
+		}
+	}
+	merged := append(l.all(), es...)
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].t.Before(merged[j].t) })
+	return l.rewrite(merged)
+}
+
+// lastStint returns the index of the last stint sent this session, or -1 if there isn't one
+func (l *logger) lastStint() int {
+	for i := len(l.session) - 1; i >= 0; i-- {
+		if l.session[i].typ == working || l.session[i].typ == resting {
+			return i
+		}
+	}
+	return -1
+}
+
+// annotate sets the note on the last stint sent this session
+func (l *logger) annotate(note string) {
+	if i := l.lastStint(); i >= 0 {
+		l.session[i].n = note
 	}
-	f.Close()
 }
 
 func sameDay(a, b time.Time) bool {
@@ -87,6 +230,7 @@ func zero(buf []entry) []entry {
 	for i := range buf {
 		buf[i].t = time.Time{}
 		buf[i].d = 0
+		buf[i].n = ""
 	}
 	return buf
 }
@@ -94,10 +238,10 @@ func zero(buf []entry) []entry {
 func (l *logger) shrink(activities []string) {
 	l.bidx = 0
 	l.sidx = 0
-	f, err := os.Create(filepath.Join(logpath, logname))
-	if err != nil {
+	if l.read(); l.bad != nil {
 		return
 	}
+	var f strings.Builder
 	actIdx := make(map[string]int)
 	for i, v := range activities {
 		actIdx[v] = i * 2
@@ -123,6 +267,10 @@ func (l *logger) shrink(activities []string) {
 			buffer = zero(buffer)
 			this = e.t
 		}
+		if e.typ == leave {
+			f.WriteString(e.String())
+			continue
+		}
 		if e.typ != working && e.typ != resting {
 			continue
 		}
@@ -135,25 +283,64 @@ func (l *logger) shrink(activities []string) {
 		}
 		buffer[idx].t = e.t
 		buffer[idx].d += e.d
+		if e.n != "" {
+			if buffer[idx].n != "" {
+				buffer[idx].n += "; "
+			}
+			buffer[idx].n += e.n
+		}
+	}
+	for _, v := range activities { // keep archived activities archived
+		if l.archived[v] {
+			f.WriteString(entry{a: v, typ: archiving, t: time.Now()}.String())
+		}
+	}
+	if replaceLog(func(w io.Writer) error {
+		_, err := io.WriteString(w, f.String())
+		return err
+	}) != nil {
+		return
 	}
-	f.Close()
 	l.buffered = append(l.buffered, l.session...) // move the session entries over into the buffered slice
 	l.session = l.session[:0]                     // empty the session to avoid a double entry
 }
 
-func (l *logger) next() (entry, error) {
-	if !l.bread {
-		l.bread = true
-		f, err := os.Open(filepath.Join(logpath, logname))
-		if err != nil {
-			return entry{}, err
+func (l *logger) read() error {
+	if l.bread {
+		return nil
+	}
+	l.bread = true
+	f, err := os.Open(filepath.Join(logpath, logname))
+	if err != nil {
+		return err
+	}
+	es, err := loadAll(f)
+	f.Close()
+	for i, e := range es {
+		if e.typ == renaming {
+			rename(es[:i], e.a, e.to)
 		}
-		es, err := loadAll(f)
-		f.Close()
-		if err != nil {
-			return entry{}, err
+	}
+	l.buffered = es
+	if err != nil {
+		l.bad = fmt.Errorf("%s is damaged: %v", filepath.Join(logpath, logname), err)
+		return l.bad
+	}
+	return nil
+}
+
+// rename gives entries for an activity a new name. If the new name is an existing activity the two are merged.
+func rename(es []entry, from, to string) {
+	for i := range es {
+		if es[i].a == from && es[i].typ != renaming {
+			es[i].a = to
 		}
-		l.buffered = es
+	}
+}
+
+func (l *logger) next() (entry, error) {
+	if err := l.read(); err != nil {
+		return entry{}, err
 	}
 	if l.bidx < len(l.buffered) {
 		l.bidx += 1
@@ -167,18 +354,8 @@ func (l *logger) next() (entry, error) {
 }
 
 func (l *logger) prev() (entry, error) {
-	if !l.bread {
-		l.bread = true
-		f, err := os.Open(filepath.Join(logpath, logname))
-		if err != nil {
-			return entry{}, err
-		}
-		es, err := loadAll(f)
-		f.Close()
-		if err != nil {
-			return entry{}, err
-		}
-		l.buffered = es
+	if err := l.read(); err != nil {
+		return entry{}, err
 	}
 	if l.sidx < len(l.session) {
 		l.sidx += 1
@@ -194,14 +371,16 @@ func (l *logger) prev() (entry, error) {
 func loadAll(f *os.File) ([]entry, error) {
 	ret := make([]entry, 0, 1000)
 	s := bufio.NewScanner(f)
-	var err error
-	for e, err := load(s); err == nil; e, err = load(s) {
+	for {
+		e, err := load(s)
+		if err == io.EOF {
+			return ret, nil
+		}
+		if err != nil {
+			return ret, fmt.Errorf("bad entry at line %d: %v", len(ret)*2+1, err)
+		}
 		ret = append(ret, e)
 	}
-	if err == io.EOF {
-		return ret, nil
-	}
-	return ret, err
 }
 
 func load(s *bufio.Scanner) (entry, error) {
@@ -218,11 +397,11 @@ func load(s *bufio.Scanner) (entry, error) {
 	if !s.Scan() {
 		err := s.Err()
 		if err == nil {
-			err = io.EOF
+			err = io.ErrUnexpectedEOF
 		}
 		return entry{}, err
 	}
-	triplet := strings.SplitN(s.Text(), " ", 3)
+	triplet := strings.SplitN(s.Text(), " ", 4)
 	if len(triplet) < 1 || len(triplet[0]) != 1 {
 		return entry{}, errors.New("bad entry")
 	}
@@ -231,21 +410,45 @@ func load(s *bufio.Scanner) (entry, error) {
 		e.typ = selecting
 	case "d":
 		e.typ = removing
+	case "r":
+		e.typ = renaming
+	case "x":
+		e.typ = archiving
+	case "u":
+		e.typ = restoring
 	case "w":
 		e.typ = working
 	case "b":
 		e.typ = resting
+	case "l":
+		e.typ = leave
 	default:
 		return entry{}, errors.New("bad entry")
 	}
-	if e.typ == selecting || e.typ == removing {
-		return e, nil
+	if len(triplet) < 2 {
+		if e.typ == selecting || e.typ == removing {
+			return e, nil
+		}
+		return entry{}, errors.New("bad entry")
 	}
 	et, err := time.Parse(time.RFC3339, triplet[1])
 	if err != nil {
 		return entry{}, err
 	}
 	e.t = et
+	if e.typ == selecting || e.typ == removing || e.typ == archiving || e.typ == restoring {
+		return e, nil
+	}
+	if len(triplet) < 3 {
+		return entry{}, errors.New("bad entry")
+	}
+	if e.typ == renaming {
+		e.to = strings.Join(triplet[2:], " ")
+		return e, nil
+	}
+	if len(triplet) == 4 {
+		e.n = triplet[3]
+	}
 	ed, err := time.ParseDuration(triplet[2])
 	if err != nil {
 		return entry{}, err
@@ -258,13 +461,16 @@ func (l *logger) tally(activities []string) [][2]time.Duration {
 	l.bidx = 0
 	l.sidx = 0
 	ret := make([][2]time.Duration, len(activities))
-	y, m, d := time.Now().Date()
+	today := startOfDay(time.Now())
+	// leave can be logged ahead of time, so check the type before the date and only stop at stints before today
 	for e, err := l.prev(); err == nil; e, err = l.prev() {
-		yy, mm, dd := e.t.Date()
-		if y != yy || m != mm || d != dd {
+		if e.typ != working && e.typ != resting {
+			continue
+		}
+		if e.t.Before(today) {
 			break
 		}
-		if e.typ != working && e.typ != resting {
+		if !sameDay(e.t.Local(), today) {
 			continue
 		}
 		for i, v := range activities {
@@ -286,9 +492,20 @@ func (l *logger) tally(activities []string) [][2]time.Duration {
 func (l *logger) refresh() ([]string, int, [2]int, [2]int, int, int) {
 	l.bidx, l.sidx = 0, 0
 	scratch := make(map[string]struct{})
+	clear(l.archived)
+	clear(l.used)
 	for e, err := l.next(); err == nil; e, err = l.next() {
-		if e.typ == removing {
+		lastUsed(e, l.used)
+		switch e.typ {
+		case removing, renaming:
 			delete(scratch, e.a)
+			delete(l.archived, e.a)
+			continue
+		case archiving:
+			l.archived[e.a] = true
+		case restoring:
+			delete(l.archived, e.a)
+		case leave: // not an activity
 			continue
 		}
 		if _, ok := scratch[e.a]; ok {
@@ -300,10 +517,10 @@ func (l *logger) refresh() ([]string, int, [2]int, [2]int, int, int) {
 	l.bidx, l.sidx = 0, 0
 	var this string
 	for e, err := l.prev(); err == nil; e, err = l.prev() {
-		if e.typ == removing {
+		if e.typ != selecting && e.typ != working && e.typ != resting {
 			continue
 		}
-		if _, ok := scratch[e.a]; ok {
+		if _, ok := scratch[e.a]; ok && !l.archived[e.a] {
 			this = e.a
 			break
 		}
@@ -360,11 +577,12 @@ func fmtDuration(d time.Duration) string {
 	return fmt.Sprintf("%dh%02dm", h, m)
 }
 
+// toRows makes work, break and total rows for the report tables
 func toRows(d [][2]time.Duration) []table.Row {
 	ret := make([]table.Row, 3)
-	ret[0] = make(table.Row, len(d)+2)
-	ret[1] = make(table.Row, len(d)+2)
-	ret[2] = make(table.Row, len(d)+2)
+	for i := range ret {
+		ret[i] = make(table.Row, len(d)+2)
+	}
 	totals := [2]time.Duration{}
 	ret[0][0] = "work"
 	ret[1][0] = "break"
@@ -382,6 +600,40 @@ func toRows(d [][2]time.Duration) []table.Row {
 	return ret
 }
 
+// amountRows adds a row for each currency billed in a range to the report tables, with col giving the column for a day.
+// Amounts come from the timesheet so they're rounded the same way as in the report and timesheet commands.
+func (l *logger) amountRows(rows []table.Row, activity string, rollup bool, start, end time.Time, col func(time.Time) int) []table.Row {
+	var curs []string
+	amounts := make(map[string][]float64)
+	for _, r := range timesheet(l.stints(activity, rollup, start, end), l.meta) {
+		rt, ok := cfg.rate(r.activity, l.meta[r.activity])
+		if !ok {
+			continue
+		}
+		a, ok := amounts[rt.Currency]
+		if !ok {
+			curs = append(curs, rt.Currency)
+			a = make([]float64, len(rows[0])-1)
+		}
+		a[col(r.day)] += rt.amount(r.billed)
+		a[len(a)-1] += rt.amount(r.billed)
+		amounts[rt.Currency] = a
+	}
+	sort.Strings(curs)
+	for _, c := range curs {
+		row := make(table.Row, len(rows[0]))
+		row[0] = c
+		if c == "" {
+			row[0] = "amt"
+		}
+		for i, a := range amounts[c] {
+			row[i+1] = fmtAmount(a)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
 func dayIndex(t time.Time) int {
 	day := t.Weekday()
 	if day == 0 {
@@ -390,7 +642,23 @@ func dayIndex(t time.Time) int {
 	return int(day) - 1
 }
 
-func (l *logger) weeks(activity string, week [2]int) ([]table.Row, [2]int, [2]int) {
+func (l *logger) weeks(activity string, rollup bool, week [2]int) ([]table.Row, [][2]time.Duration, [2]int, [2]int) {
+	d, nxt, prev := l.weekDays(activity, rollup, week)
+	start := isoweek.StartTime(week[0], week[1], time.Local)
+	rows := l.amountRows(toRows(d), activity, rollup, start, start.AddDate(0, 0, 7), dayIndex)
+	rows = l.compareWeeks(l.leaveRow(rows, week), activity, rollup, d, week)
+	if cfg.Goals.weekly() {
+		var total time.Duration
+		for _, v := range d {
+			total += v[0]
+		}
+		return goalCells(rows, activity, rollup, total), d, nxt, prev
+	}
+	return rows, d, nxt, prev
+}
+
+// weekDays sums the work and breaks on each day of an ISO week, and finds the weeks either side with stints
+func (l *logger) weekDays(activity string, rollup bool, week [2]int) ([][2]time.Duration, [2]int, [2]int) {
 	l.bidx = 0
 	l.sidx = 0
 	var nxt, prev [2]int
@@ -399,7 +667,7 @@ func (l *logger) weeks(activity string, week [2]int) ([]table.Row, [2]int, [2]in
 		if e.typ != working && e.typ != resting {
 			continue
 		}
-		if e.a != activity {
+		if !matches(e.a, activity, rollup) {
 			continue
 		}
 		thisYr, thisWk := e.t.ISOWeek()
@@ -414,10 +682,18 @@ func (l *logger) weeks(activity string, week [2]int) ([]table.Row, [2]int, [2]in
 		nxt[0], nxt[1] = thisYr, thisWk
 		break
 	}
-	return toRows(d), nxt, prev
+	return d, nxt, prev
+}
+
+func (l *logger) years(activity string, rollup bool, year int) ([]table.Row, [][2]time.Duration, int, int) {
+	d, nxt, prev := l.yearMonths(activity, rollup, year)
+	jan1 := time.Date(year, 1, 1, 0, 0, 0, 0, time.Local)
+	rows := l.amountRows(toRows(d), activity, rollup, jan1, jan1.AddDate(1, 0, 0), func(t time.Time) int { return int(t.Month()) - 1 })
+	return l.compareYears(rows, activity, rollup, d, year), d, nxt, prev
 }
 
-func (l *logger) years(activity string, year int) ([]table.Row, int, int) {
+// yearMonths sums the work and breaks in each month of a year, and finds the years either side with stints
+func (l *logger) yearMonths(activity string, rollup bool, year int) ([][2]time.Duration, int, int) {
 	l.bidx = 0
 	l.sidx = 0
 	var nxt, prev int
@@ -426,7 +702,7 @@ func (l *logger) years(activity string, year int) ([]table.Row, int, int) {
 		if e.typ != working && e.typ != resting {
 			continue
 		}
-		if e.a != activity {
+		if !matches(e.a, activity, rollup) {
 			continue
 		}
 		thisYr := e.t.Year()
@@ -441,5 +717,5 @@ func (l *logger) years(activity string, year int) ([]table.Row, int, int) {
 		nxt = thisYr
 		break
 	}
-	return toRows(d), nxt, prev
+	return d, nxt, prev
 }
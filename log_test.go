@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testLogger returns a logger over entries as if they'd been read from the log
+func testLogger(es ...entry) *logger {
+	return &logger{
+		meta:     make(map[string]meta),
+		archived: make(map[string]bool),
+		used:     make(map[string]time.Time),
+		bread:    true,
+		buffered: es,
+	}
+}
+
+func TestTallyFutureLeave(t *testing.T) {
+	now := time.Now()
+	lg := testLogger(
+		entry{a: "Web", typ: working, t: startOfDay(now).AddDate(0, 0, -1), d: time.Hour},
+		entry{a: "Web", typ: working, t: startOfDay(now).Add(time.Minute), d: time.Hour},
+		entry{a: "Annual leave", typ: leave, t: startOfDay(now).AddDate(0, 1, 0)},
+		entry{a: "Web", typ: resting, t: startOfDay(now).Add(2 * time.Hour), d: 10 * time.Minute}, // flushed after the leave
+	)
+	got := lg.tally([]string{"Web"})
+	if want := [2]time.Duration{time.Hour, 10 * time.Minute}; got[0] != want {
+		t.Errorf("tally with future leave = %v, want %v", got[0], want)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	at := time.Date(2024, 5, 2, 9, 30, 0, 0, time.UTC)
+	for _, e := range []entry{
+		{a: "Web", typ: selecting, t: at},
+		{a: "Client A / Feature X", typ: removing, t: at},
+		{a: "Old name", typ: renaming, t: at, to: "New name with spaces"},
+		{a: "Web", typ: archiving, t: at},
+		{a: "Web", typ: restoring, t: at},
+		{a: "Web", typ: working, t: at, d: 25 * time.Minute},
+		{a: "Web", typ: working, t: at, d: 25 * time.Minute, n: "fixed the build, then some"},
+		{a: "Web", typ: resting, t: at, d: 5 * time.Minute, n: "tea"},
+		{a: "Annual leave", typ: leave, t: at},
+		{a: "Sick leave", typ: leave, t: at, d: 3 * time.Hour, n: "dentist"},
+	} {
+		s := bufio.NewScanner(strings.NewReader(e.String()))
+		got, err := load(s)
+		if err != nil {
+			t.Errorf("load(%q): %v", e.String(), err)
+			continue
+		}
+		if !got.t.Equal(e.t) {
+			t.Errorf("load(%q) time = %v, want %v", e.String(), got.t, e.t)
+		}
+		got.t = e.t
+		if got != e {
+			t.Errorf("load(%q) = %+v, want %+v", e.String(), got, e)
+		}
+	}
+}
+
+func TestLoadAllBadEntry(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.WriteString("Web\nw 2024-05-02T09:30:00Z 25m0s\nBad\nq 2024-05-02T10:00:00Z\nWeb\nw 2024-05-02T11:00:00Z 25m0s\n")
+	f.Seek(0, io.SeekStart)
+	es, err := loadAll(f)
+	if err == nil {
+		t.Fatal("expected an error for a bad entry")
+	}
+	if len(es) != 1 {
+		t.Errorf("got %d entries before the bad one, want 1", len(es))
+	}
+}
@@ -8,6 +8,8 @@ package main
 import (
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/snabb/isoweek"
@@ -32,45 +34,93 @@ const (
 	resting
 	weekly
 	yearly
+	editing
+	renaming
+	archiving
+	restoring
+	noting
+	listing
+	dashboard
+	timeline
+	statistics
+	leave // not a view but a kind of entry in the log
 	quitting
 )
 
 type model struct {
-	log        *logger
-	activities []string
-	tally      [][2]time.Duration
-	cursor     int
-	selected   int
-	stopwatch  stopwatch.Model
-	textInput  textinput.Model
-	keymap     keymap
-	help       help.Model
-	state      state
-	statePrev  state
-	bank       time.Duration
-	week       [2]int
-	weekNxt    [2]int
-	weekPrev   [2]int
-	year       int
-	yearNxt    int
-	yearPrev   int
-	weekTbl    table.Model
-	yearTbl    table.Model
+	log          *logger
+	activities   []string
+	tally        [][2]time.Duration
+	cursor       int
+	rows         []node
+	collapsed    map[string]bool
+	rollup       bool
+	showArchived bool
+	recent       bool
+	searching    bool
+	search       textinput.Model
+	note         string
+	day          time.Time
+	selected     int
+	stopwatch    stopwatch.Model
+	textInput    textinput.Model
+	form         []textinput.Model
+	focus        int
+	keymap       keymap
+	help         help.Model
+	state        state
+	statePrev    state
+	bank         time.Duration
+	technique    technique
+	notifier     *notifier
+	hooks        *hooks
+	earlier      map[string]time.Duration // work towards weekly goals before today
+	tallied      time.Time                // the day the tally, earlier and spark were worked out
+	week         [2]int
+	weekNxt      [2]int
+	weekPrev     [2]int
+	year         int
+	yearNxt      int
+	yearPrev     int
+	weekTbl      table.Model
+	weekSums     [][2]time.Duration // for the chart under the weekly report
+	yearSums     [][2]time.Duration
+	yearDays     map[string]time.Duration // for the heatmap under the yearly report
+	spark        []time.Duration          // daily work before today for the sparkline
+	stats        stats
+	yearTbl      table.Model
+	entryTbl     table.Model
 }
 
 type keymap struct {
-	add    key.Binding
-	change key.Binding
-	delete key.Binding
-	work   key.Binding
-	rest   key.Binding
-	stop   key.Binding
-	week   key.Binding
-	year   key.Binding
-	shrink key.Binding
-	next   key.Binding
-	prev   key.Binding
-	quit   key.Binding
+	add          key.Binding
+	change       key.Binding
+	delete       key.Binding
+	edit         key.Binding
+	rename       key.Binding
+	archive      key.Binding
+	showArchived key.Binding
+	search       key.Binding
+	order        key.Binding
+	pin          key.Binding
+	quick        key.Binding
+	fold         key.Binding
+	work         key.Binding
+	rest         key.Binding
+	stop         key.Binding
+	note         key.Binding
+	list         key.Binding
+	dashboard    key.Binding
+	timeline     key.Binding
+	stats        key.Binding
+	scroll       key.Binding
+	week         key.Binding
+	year         key.Binding
+	shrink       key.Binding
+	rollup       key.Binding
+	next         key.Binding
+	prev         key.Binding
+	quit         key.Binding
 }
 
 func (m model) Init() tea.Cmd {
@@ -81,6 +131,8 @@ var style = lipgloss.NewStyle().Foreground(lipgloss.Color("#3C3C3C"))
 
 var hstyle = lipgloss.NewStyle().Bold(true)
 
+var alertStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205"))
+
 var tableStyle = lipgloss.NewStyle().
 	BorderStyle(lipgloss.NormalBorder()).
 	BorderForeground(lipgloss.Color("240"))
@@ -104,48 +156,78 @@ func (m model) View() string {
 	case ready:
 		return fmt.Sprintf("Hit 'w' to start working on %s\n%s\n%s", m.activities[m.selected], m.statusView(), m.helpView())
 	case working:
-		return fmt.Sprintf("Working for %s on %s\n%s\n%s", m.stopwatch.Elapsed(), m.activities[m.selected], m.statusView(), m.helpView())
+		return fmt.Sprintf("%s\n%s\n%s", m.clockView(), m.statusView(), m.helpView())
 	case resting:
-		return fmt.Sprintf("Breaking for %s from %s\n%s\n%s", m.stopwatch.Elapsed(), m.activities[m.selected], m.statusView(), m.helpView())
+		return fmt.Sprintf("%s\n%s\n%s", m.clockView(), m.statusView(), m.helpView())
 	case selecting, removing:
 		// Iterate over our choices
 		var s string
-		if m.state == selecting {
+		switch {
+		case m.switching():
+			verb := "Working for %s on %s"
+			if m.statePrev == resting {
+				verb = "Breaking for %s from %s"
+			}
+			s = fmt.Sprintf(verb+", switch to:\n", m.stopwatch.Elapsed(), m.activities[m.selected])
+		case m.state == selecting:
 			s = "Select activity:\n"
-		} else {
+		default:
 			s = "Delete activity:\n"
 		}
-		for i, activity := range m.activities {
-			cursor, checked := " ", " "
-			if m.cursor == i {
-				cursor = ">"
-				checked = "x"
-			}
-			// Render the row
-			s += fmt.Sprintf("%s [%s] %s\n", cursor, checked, activity)
-		}
+		s += m.treeView()
 		return s + "\n" + m.help.ShortHelpView([]key.Binding{
 			m.keymap.add,
 			m.keymap.change,
 			m.keymap.delete,
+			m.keymap.edit,
+			m.keymap.rename,
+			m.keymap.archive,
+			m.keymap.showArchived,
+			m.keymap.search,
+			m.keymap.order,
+			m.keymap.pin,
+			m.keymap.quick,
+			m.keymap.fold,
 			m.keymap.quit,
 		})
+	case editing:
+		return m.formView()
+	case noting:
+		return m.noteView()
+	case listing:
+		return m.entriesView()
+	case dashboard:
+		return m.dashboardView()
+	case timeline:
+		return m.timelineView()
+	case statistics:
+		return m.statsView()
+	case renaming:
+		return fmt.Sprintf(
+			"Rename %s:\n%s\n\n%s",
+			m.activities[m.rows[m.cursor].idx],
+			m.textInput.View(),
+			style.Render("Enter an existing activity to merge into it • (esc) to cancel"),
+		)
 	case weekly:
-		hdr := fmt.Sprintf("Weekly report for %s (%s):", m.activities[m.selected],
+		hdr := fmt.Sprintf("Weekly report for %s%s (%s):", m.activities[m.selected], m.rollupView(),
 			isoweek.StartTime(m.week[0], m.week[1], time.UTC).Format(time.DateOnly),
 		)
-		return fmt.Sprintf("%s\n%s\n%s",
+		return fmt.Sprintf("%s\n%s\n%s\n%s",
 			hstyle.Render(hdr),
 			tableStyle.Render(m.weekTbl.View()),
+			columnChart(dayLabels, m.weekSums, 5),
 			m.helpView(),
 		)
 	case yearly:
-		hdr := fmt.Sprintf("Yearly report for %s (%d):", m.activities[m.selected],
+		hdr := fmt.Sprintf("Yearly report for %s%s (%d):", m.activities[m.selected], m.rollupView(),
 			m.year,
 		)
-		return fmt.Sprintf("%s\n%s\n%s",
+		return fmt.Sprintf("%s\n%s\n%s\n\n%s\n%s",
 			hstyle.Render(hdr),
 			tableStyle.Render(m.yearTbl.View()),
+			columnChart(monthLabels, m.yearSums, 5),
+			heatmap(m.year, m.yearDays),
 			m.helpView(),
 		)
 	}
@@ -153,13 +235,25 @@ func (m model) View() string {
 }
 
 func (m model) statusView() string {
-	str := fmt.Sprintf("Bank: %s\nDaily tally: %s, %s, %s",
-		m.bank.Round(time.Second),
+	str := fmt.Sprintf("%s\nDaily tally: %s, %s, %s",
+		m.technique.status(m.bank),
 		m.tally[m.selected][0].Round(time.Second),
 		m.tally[m.selected][1].Round(time.Second),
 		(m.tally[m.selected][0] + m.tally[m.selected][1]).Round(time.Second),
 	)
-	return style.Render(str)
+	if m.note != "" {
+		str += "\nNote: " + m.note
+	}
+	str = style.Render(str)
+	if sv := m.sparkView(); sv != "" {
+		str += "\n" + sv
+	}
+	if cfg.Goals.set() {
+		if gv := m.goalsView(); gv != "" {
+			str += "\n" + gv
+		}
+	}
+	return str
 }
 
 func (m model) helpView() string {
@@ -167,10 +261,17 @@ func (m model) helpView() string {
 		m.keymap.work,
 		m.keymap.rest,
 		m.keymap.change,
+		m.keymap.note,
+		m.keymap.list,
+		m.keymap.dashboard,
+		m.keymap.timeline,
+		m.keymap.stats,
 		m.keymap.week,
 		m.keymap.year,
+		m.keymap.rollup,
 		m.keymap.next,
 		m.keymap.prev,
+		m.keymap.scroll,
 		m.keymap.shrink,
 		m.keymap.stop,
 		m.keymap.quit,
@@ -178,7 +279,11 @@ func (m model) helpView() string {
 }
 
 func (m model) switchTo(s state) model {
-	m.statePrev = m.state
+	from, prev := m.state, m.statePrev
+	// editing and renaming are sub-views of selecting, and going back to selecting from itself keeps the state it was opened from
+	if from != editing && s != editing && from != renaming && s != renaming && (from != selecting || s != selecting) {
+		m.statePrev = from
+	}
 	if (s == ready || s == selecting || s == removing) && len(m.activities) == 0 {
 		m.state = adding
 	} else {
@@ -192,9 +297,16 @@ func (m model) switchTo(s state) model {
 		m.keymap.work.SetEnabled(true)
 		m.keymap.rest.SetEnabled(false)
 		m.keymap.stop.SetEnabled(false)
+		m.keymap.note.SetEnabled(m.log.lastStint() >= 0)
+		m.keymap.list.SetEnabled(true)
+		m.keymap.dashboard.SetEnabled(true)
+		m.keymap.timeline.SetEnabled(true)
+		m.keymap.stats.SetEnabled(true)
+		m.keymap.scroll.SetEnabled(false)
 		m.keymap.week.SetEnabled(m.week[0] > 0)
 		m.keymap.year.SetEnabled(m.year > 0)
 		m.keymap.shrink.SetEnabled(false)
+		m.keymap.rollup.SetEnabled(false)
 		m.keymap.next.SetEnabled(false)
 		m.keymap.prev.SetEnabled(false)
 		m.keymap.quit.SetEnabled(true)
@@ -212,10 +324,21 @@ func (m model) switchTo(s state) model {
 		m.keymap.prev.SetEnabled(false)
 		m.keymap.quit.SetEnabled(false)
 	case removing:
-		m.cursor = m.selected
+		m.searching = false
+		m.search.Reset()
+		m = m.expandTo(m.selected)
 		m.keymap.add.SetEnabled(false)
 		m.keymap.change.SetEnabled(true)
 		m.keymap.delete.SetEnabled(false)
+		m.keymap.edit.SetEnabled(false)
+		m.keymap.rename.SetEnabled(false)
+		m.keymap.archive.SetEnabled(false)
+		m.keymap.showArchived.SetEnabled(false)
+		m.keymap.quick.SetEnabled(false)
+		m.keymap.search.SetEnabled(false)
+		m.keymap.order.SetEnabled(false)
+		m.keymap.pin.SetEnabled(false)
+		m.keymap.fold.SetEnabled(false)
 		m.keymap.work.SetEnabled(false)
 		m.keymap.rest.SetEnabled(false)
 		m.keymap.stop.SetEnabled(false)
@@ -226,10 +349,23 @@ func (m model) switchTo(s state) model {
 		m.keymap.prev.SetEnabled(false)
 		m.keymap.quit.SetEnabled(true)
 	case selecting:
-		m.cursor = m.selected
-		m.keymap.add.SetEnabled(true)
+		if from != editing && from != renaming {
+			m.searching = false
+			m.search.Reset()
+			m = m.expandTo(m.selected)
+		}
+		m.keymap.add.SetEnabled(!m.switching())
 		m.keymap.change.SetEnabled(false)
-		m.keymap.delete.SetEnabled(true)
+		m.keymap.delete.SetEnabled(!m.switching())
+		m.keymap.edit.SetEnabled(true)
+		m.keymap.rename.SetEnabled(true)
+		m.keymap.archive.SetEnabled(true)
+		m.keymap.showArchived.SetEnabled(len(m.log.archived) > 0)
+		m.keymap.quick.SetEnabled(true)
+		m.keymap.search.SetEnabled(true)
+		m.keymap.order.SetEnabled(true)
+		m.keymap.pin.SetEnabled(true)
+		m.keymap.fold.SetEnabled(true)
 		m.keymap.work.SetEnabled(false)
 		m.keymap.rest.SetEnabled(false)
 		m.keymap.stop.SetEnabled(false)
@@ -241,72 +377,222 @@ func (m model) switchTo(s state) model {
 		m.keymap.quit.SetEnabled(true)
 	case working:
 		m.keymap.add.SetEnabled(false)
-		m.keymap.change.SetEnabled(false)
+		m.keymap.change.SetEnabled(true)
 		m.keymap.delete.SetEnabled(false)
 		m.keymap.work.SetEnabled(false)
 		m.keymap.rest.SetEnabled(true)
 		m.keymap.stop.SetEnabled(true)
+		m.keymap.note.SetEnabled(true)
+		m.keymap.list.SetEnabled(false)
+		m.keymap.dashboard.SetEnabled(true)
+		m.keymap.timeline.SetEnabled(true)
+		m.keymap.stats.SetEnabled(false)
+		m.keymap.scroll.SetEnabled(false)
 		m.keymap.week.SetEnabled(false)
 		m.keymap.year.SetEnabled(false)
 		m.keymap.shrink.SetEnabled(false)
+		m.keymap.rollup.SetEnabled(false)
 		m.keymap.next.SetEnabled(false)
 		m.keymap.prev.SetEnabled(false)
 		m.keymap.quit.SetEnabled(true)
 	case resting:
 		m.keymap.add.SetEnabled(false)
-		m.keymap.change.SetEnabled(false)
+		m.keymap.change.SetEnabled(true)
 		m.keymap.delete.SetEnabled(false)
 		m.keymap.work.SetEnabled(true)
 		m.keymap.rest.SetEnabled(false)
 		m.keymap.stop.SetEnabled(true)
+		m.keymap.note.SetEnabled(true)
+		m.keymap.list.SetEnabled(false)
+		m.keymap.dashboard.SetEnabled(true)
+		m.keymap.timeline.SetEnabled(true)
+		m.keymap.stats.SetEnabled(false)
+		m.keymap.scroll.SetEnabled(false)
 		m.keymap.week.SetEnabled(false)
 		m.keymap.year.SetEnabled(false)
 		m.keymap.shrink.SetEnabled(false)
+		m.keymap.rollup.SetEnabled(false)
 		m.keymap.next.SetEnabled(false)
 		m.keymap.prev.SetEnabled(false)
 		m.keymap.quit.SetEnabled(true)
 	case weekly:
-		rows, nxt, prev := m.log.weeks(m.activities[m.selected], m.week)
+		rows, sums, nxt, prev := m.log.weeks(m.activities[m.selected], m.rollup, m.week)
+		m.weekSums = sums
 		m.weekNxt = nxt
 		m.weekPrev = prev
 		m.weekTbl.SetRows(rows)
+		m.weekTbl.SetHeight(len(rows))
 		m.keymap.add.SetEnabled(false)
 		m.keymap.change.SetEnabled(true)
 		m.keymap.delete.SetEnabled(false)
 		m.keymap.work.SetEnabled(true)
 		m.keymap.rest.SetEnabled(false)
 		m.keymap.stop.SetEnabled(false)
+		m.keymap.note.SetEnabled(false)
+		m.keymap.list.SetEnabled(false)
+		m.keymap.dashboard.SetEnabled(false)
+		m.keymap.timeline.SetEnabled(false)
+		m.keymap.stats.SetEnabled(false)
+		m.keymap.scroll.SetEnabled(false)
 		m.keymap.week.SetEnabled(false)
 		m.keymap.year.SetEnabled(true)
 		m.keymap.shrink.SetEnabled(true)
+		m.keymap.rollup.SetEnabled(m.hasChildren())
 		m.keymap.next.SetEnabled(m.weekNxt[0] > 0)
 		m.keymap.prev.SetEnabled(m.weekPrev[0] > 0)
 		m.keymap.quit.SetEnabled(true)
 	case yearly:
-		rows, nxt, prev := m.log.years(m.activities[m.selected], m.year)
+		rows, sums, nxt, prev := m.log.years(m.activities[m.selected], m.rollup, m.year)
+		m.yearSums = sums
+		jan1 := time.Date(m.year, 1, 1, 0, 0, 0, 0, time.Local)
+		m.yearDays = daily(m.log.stints(m.activities[m.selected], m.rollup, jan1, jan1.AddDate(1, 0, 0)))
 		m.yearNxt = nxt
 		m.yearPrev = prev
 		m.yearTbl.SetRows(rows)
+		m.yearTbl.SetHeight(len(rows))
 		m.keymap.add.SetEnabled(false)
 		m.keymap.change.SetEnabled(true)
 		m.keymap.delete.SetEnabled(false)
 		m.keymap.work.SetEnabled(true)
 		m.keymap.rest.SetEnabled(false)
 		m.keymap.stop.SetEnabled(false)
+		m.keymap.note.SetEnabled(false)
+		m.keymap.list.SetEnabled(false)
+		m.keymap.dashboard.SetEnabled(false)
+		m.keymap.timeline.SetEnabled(false)
+		m.keymap.stats.SetEnabled(false)
+		m.keymap.scroll.SetEnabled(false)
 		m.keymap.week.SetEnabled(true)
 		m.keymap.year.SetEnabled(false)
 		m.keymap.shrink.SetEnabled(true)
+		m.keymap.rollup.SetEnabled(m.hasChildren())
 		m.keymap.next.SetEnabled(m.yearNxt > 0)
 		m.keymap.prev.SetEnabled(m.yearPrev > 0)
 		m.keymap.quit.SetEnabled(true)
+	case renaming:
+		m.textInput.SetValue(m.activities[m.rows[m.cursor].idx])
+		m.textInput.CursorEnd()
+	case noting:
+		if m.statePrev != ready {
+			m.textInput.SetValue(m.note)
+		} else if i := m.log.lastStint(); i >= 0 {
+			m.textInput.SetValue(m.log.session[i].n)
+		}
+		m.textInput.CursorEnd()
+	case listing:
+		rows := entryRows(m.log.day(m.day))
+		m.entryTbl.SetRows(rows)
+		m.entryTbl.SetHeight(min(max(len(rows), 1), 15))
+		m.entryTbl.GotoBottom()
+		m.keymap.add.SetEnabled(false)
+		m.keymap.change.SetEnabled(false)
+		m.keymap.delete.SetEnabled(false)
+		m.keymap.work.SetEnabled(false)
+		m.keymap.rest.SetEnabled(false)
+		m.keymap.stop.SetEnabled(false)
+		m.keymap.note.SetEnabled(false)
+		m.keymap.list.SetEnabled(false)
+		m.keymap.dashboard.SetEnabled(false)
+		m.keymap.timeline.SetEnabled(false)
+		m.keymap.stats.SetEnabled(false)
+		m.keymap.scroll.SetEnabled(false)
+		m.keymap.week.SetEnabled(false)
+		m.keymap.year.SetEnabled(false)
+		m.keymap.rollup.SetEnabled(false)
+		m.keymap.shrink.SetEnabled(false)
+		m.keymap.next.SetEnabled(true)
+		m.keymap.prev.SetEnabled(true)
+		m.keymap.quit.SetEnabled(true)
+	case dashboard:
+		m.keymap.add.SetEnabled(false)
+		m.keymap.change.SetEnabled(false)
+		m.keymap.delete.SetEnabled(false)
+		m.keymap.work.SetEnabled(false)
+		m.keymap.rest.SetEnabled(false)
+		m.keymap.stop.SetEnabled(false)
+		m.keymap.note.SetEnabled(false)
+		m.keymap.list.SetEnabled(false)
+		m.keymap.dashboard.SetEnabled(true)
+		m.keymap.timeline.SetEnabled(false)
+		m.keymap.stats.SetEnabled(false)
+		m.keymap.scroll.SetEnabled(false)
+		m.keymap.week.SetEnabled(false)
+		m.keymap.year.SetEnabled(false)
+		m.keymap.rollup.SetEnabled(false)
+		m.keymap.shrink.SetEnabled(false)
+		m.keymap.next.SetEnabled(false)
+		m.keymap.prev.SetEnabled(false)
+		m.keymap.quit.SetEnabled(true)
+	case timeline:
+		m.keymap.add.SetEnabled(false)
+		m.keymap.change.SetEnabled(false)
+		m.keymap.delete.SetEnabled(false)
+		m.keymap.work.SetEnabled(false)
+		m.keymap.rest.SetEnabled(false)
+		m.keymap.stop.SetEnabled(false)
+		m.keymap.note.SetEnabled(false)
+		m.keymap.list.SetEnabled(false)
+		m.keymap.dashboard.SetEnabled(false)
+		m.keymap.timeline.SetEnabled(true)
+		m.keymap.stats.SetEnabled(false)
+		m.keymap.scroll.SetEnabled(true)
+		m.keymap.week.SetEnabled(false)
+		m.keymap.year.SetEnabled(false)
+		m.keymap.rollup.SetEnabled(false)
+		m.keymap.shrink.SetEnabled(false)
+		m.keymap.next.SetEnabled(true)
+		m.keymap.prev.SetEnabled(true)
+		m.keymap.quit.SetEnabled(true)
+	case statistics:
+		m.stats = m.lastWeeks()
+		m.keymap.add.SetEnabled(false)
+		m.keymap.change.SetEnabled(false)
+		m.keymap.delete.SetEnabled(false)
+		m.keymap.work.SetEnabled(false)
+		m.keymap.rest.SetEnabled(false)
+		m.keymap.stop.SetEnabled(false)
+		m.keymap.note.SetEnabled(false)
+		m.keymap.list.SetEnabled(false)
+		m.keymap.dashboard.SetEnabled(false)
+		m.keymap.timeline.SetEnabled(false)
+		m.keymap.stats.SetEnabled(true)
+		m.keymap.scroll.SetEnabled(false)
+		m.keymap.week.SetEnabled(false)
+		m.keymap.year.SetEnabled(false)
+		m.keymap.rollup.SetEnabled(m.hasChildren())
+		m.keymap.shrink.SetEnabled(false)
+		m.keymap.next.SetEnabled(false)
+		m.keymap.prev.SetEnabled(false)
+		m.keymap.quit.SetEnabled(true)
+	case editing:
+		m.form = newForm(m.log.meta[m.activities[m.rows[m.cursor].idx]])
+		m.focus = 0
 	case quitting:
 		m.log.flush()
 	}
+	if event, ended := m.transition(from, prev); event != "" {
+		m.hook(event, from, ended)
+	}
 	return m
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
+	// keep the clock running whatever the view
+	switch msg.(type) {
+	case stopwatch.TickMsg:
+		m.stopwatch, cmd = m.stopwatch.Update(msg)
+		if now := time.Now(); !sameDay(now, m.tallied) {
+			m.tallied = startOfDay(now)
+			m.tally = m.log.tally(m.activities)
+			m.earlier = m.log.earlier()
+			m.spark = m.log.sparkDays(cfg.sparkWeeks())
+		}
+		return m, tea.Batch(cmd, m.checkNotify())
+	case stopwatch.StartStopMsg, stopwatch.ResetMsg:
+		m.stopwatch, cmd = m.stopwatch.Update(msg)
+		return m, cmd
+	}
 	switch m.state {
 	case adding:
 		switch msg := msg.(type) {
@@ -327,49 +613,171 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.yearNxt = 0
 				m.tally = m.log.tally(m.activities)
 				m.textInput.Reset()
+				m.hook("add", adding, false)
 				return m.switchTo(ready), nil
 			}
 		}
 		m.textInput, cmd = m.textInput.Update(msg)
 		return m, cmd
+	case editing:
+		return m.updateForm(msg)
+	case noting:
+		return m.updateNote(msg)
+	case listing:
+		return m.updateEntries(msg)
+	case dashboard:
+		return m.updateDashboard(msg)
+	case timeline:
+		return m.updateTimeline(msg)
+	case statistics:
+		return m.updateStats(msg)
+	case renaming:
+		switch msg := msg.(type) {
+		case tea.KeyMsg:
+			switch msg.Type {
+			case tea.KeyEsc:
+				m.textInput.Reset()
+				return m.switchTo(selecting), nil
+			case tea.KeyCtrlC:
+				return m.switchTo(quitting), tea.Quit
+			case tea.KeyEnter:
+				from, to := m.activities[m.rows[m.cursor].idx], strings.TrimSpace(m.textInput.Value())
+				m.textInput.Reset()
+				if to == "" || to == from {
+					return m.switchTo(selecting), nil
+				}
+				sel := m.activities[m.selected]
+				if sel == from {
+					sel = to
+				}
+				m.log.send(entry{a: from, typ: renaming, t: time.Now(), to: to})
+				m.activities, m.selected, m.week, m.weekPrev, m.year, m.yearPrev = m.log.refresh()
+				m.weekNxt = [2]int{}
+				m.yearNxt = 0
+				m.tally = m.log.tally(m.activities)
+				m.selected = max(slices.Index(m.activities, sel), 0)
+				m = m.expandTo(slices.Index(m.activities, to))
+				return m.switchTo(selecting), nil
+			}
+		}
+		m.textInput, cmd = m.textInput.Update(msg)
+		return m, cmd
 	case selecting, removing:
+		if m.searching {
+			return m.updateSearch(msg)
+		}
 		// Is it a key press?
 		switch msg := msg.(type) {
 		case tea.KeyMsg:
 			switch msg.String() {
-			case "a":
-				return m.switchTo(adding), nil
+			case "a", "d":
+				if m.switching() { // the clock's running so finish switching first
+					return m, nil
+				}
+				if msg.String() == "a" {
+					return m.switchTo(adding), nil
+				}
+				return m.switchTo(removing), nil
 			case "ctrl+c", "q":
+				if m.switching() {
+					m = m.logStint(m.statePrev, time.Now())
+				}
 				return m.switchTo(quitting), tea.Quit
-			case "d":
-				return m.switchTo(removing), nil
+			case "esc":
+				if m.switching() {
+					return m.switchTo(m.statePrev), nil
+				}
 			case "c":
 				return m.switchTo(selecting), nil
+			case "v":
+				if !m.keymap.showArchived.Enabled() {
+					return m, nil
+				}
+				m.showArchived = !m.showArchived
+				if m.showArchived {
+					m.keymap.showArchived.SetHelp("v", "hide archived")
+				} else {
+					m.keymap.showArchived.SetHelp("v", "show archived")
+				}
+				if len(m.rows) == 0 {
+					return m.refreshRows(""), nil
+				}
+				return m.refreshRows(m.rows[m.cursor].path), nil
+			case "x":
+				if !m.keymap.archive.Enabled() || len(m.rows) == 0 || m.rows[m.cursor].idx < 0 {
+					return m, nil
+				}
+				n := m.rows[m.cursor]
+				typ := archiving
+				if m.log.archived[m.activities[n.idx]] {
+					typ = restoring
+				}
+				sel := m.activities[m.selected]
+				m.log.send(entry{a: m.activities[n.idx], typ: typ, t: time.Now()})
+				m.activities, m.selected, m.week, m.weekPrev, m.year, m.yearPrev = m.log.refresh()
+				m.weekNxt = [2]int{}
+				m.yearNxt = 0
+				m.tally = m.log.tally(m.activities)
+				m.selected = max(slices.Index(m.activities, sel), 0)
+				m.keymap.showArchived.SetEnabled(len(m.log.archived) > 0)
+				return m.refreshRows(n.path), nil
+			case "e", "m":
+				if !m.keymap.edit.Enabled() || len(m.rows) == 0 || m.rows[m.cursor].idx < 0 {
+					return m, nil
+				}
+				if msg.String() == "m" {
+					return m.switchTo(renaming), nil
+				}
+				return m.switchTo(editing), nil
 			case "up", "k":
 				if m.cursor > 0 {
 					m.cursor--
 				}
 			case "down", "j":
-				if m.cursor < len(m.activities)-1 {
+				if m.cursor < len(m.rows)-1 {
 					m.cursor++
 				}
+			case "left", "h":
+				if m.keymap.fold.Enabled() {
+					return m.collapse(), nil
+				}
+			case "right", "l":
+				if m.keymap.fold.Enabled() {
+					return m.expand(), nil
+				}
 			case "enter", " ":
-				// remove or select
-				if m.selected != m.cursor {
-					m.selected = m.cursor
+				return m.choose()
+			case "/":
+				if !m.keymap.search.Enabled() {
+					return m, nil
 				}
-				m.log.send(entry{a: m.activities[m.selected], typ: m.state, t: time.Now()})
-				if m.state == selecting {
-					if m.statePrev == weekly || m.statePrev == yearly {
-						return m.switchTo(m.statePrev), nil
-					}
-					return m.switchTo(ready), nil
+				m.searching = true
+				return m, m.search.Focus()
+			case "o":
+				if !m.keymap.order.Enabled() {
+					return m, nil
+				}
+				m.recent = !m.recent
+				if m.recent {
+					m.keymap.order.SetHelp("o", "a-z")
+				} else {
+					m.keymap.order.SetHelp("o", "recent first")
+				}
+				if len(m.rows) == 0 {
+					return m.refreshRows(""), nil
+				}
+				return m.refreshRows(m.rows[m.cursor].path), nil
+			case "f":
+				if !m.keymap.pin.Enabled() {
+					return m, nil
+				}
+				return m.togglePin(), nil
+			case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+				quick := m.quick()
+				if i := int(msg.String()[0] - '1'); m.state == selecting && i < len(quick) {
+					m.cursor = quick[i]
+					return m.choose()
 				}
-				m.activities, m.selected, m.week, m.weekPrev, m.year, m.yearPrev = m.log.refresh()
-				m.weekNxt = [2]int{}
-				m.yearNxt = 0
-				m.tally = m.log.tally(m.activities)
-				return m.switchTo(removing), nil
 			}
 		}
 		return m, cmd
@@ -387,6 +795,18 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m.switchTo(weekly), cmd
 			case key.Matches(msg, m.keymap.year):
 				return m.switchTo(yearly), cmd
+			case key.Matches(msg, m.keymap.note):
+				return m.switchTo(noting), nil
+			case key.Matches(msg, m.keymap.list):
+				m.day = time.Now()
+				return m.switchTo(listing), nil
+			case key.Matches(msg, m.keymap.stats):
+				return m.switchTo(statistics), nil
+			case key.Matches(msg, m.keymap.dashboard):
+				return m.switchTo(dashboard), nil
+			case key.Matches(msg, m.keymap.timeline):
+				m.day = startOfWeek(time.Now())
+				return m.switchTo(timeline), nil
 			}
 		}
 		return m, nil
@@ -395,26 +815,35 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case tea.KeyMsg:
 			switch {
 			case key.Matches(msg, m.keymap.quit):
-				m.log.send(entry{a: m.activities[m.selected], typ: m.state, t: time.Now(), d: m.stopwatch.Elapsed()})
+				m = m.logStint(m.state, time.Now())
 				return m.switchTo(quitting), tea.Quit
+			case key.Matches(msg, m.keymap.note):
+				return m.switchTo(noting), nil
+			case key.Matches(msg, m.keymap.dashboard):
+				return m.switchTo(dashboard), nil
+			case key.Matches(msg, m.keymap.timeline):
+				m.day = startOfWeek(time.Now())
+				return m.switchTo(timeline), nil
+			case key.Matches(msg, m.keymap.change):
+				return m.switchTo(selecting), nil
 			case key.Matches(msg, m.keymap.stop):
 				if m.state == working {
 					m.tally[m.selected][0] += m.stopwatch.Elapsed()
 				} else {
 					m.tally[m.selected][1] += m.stopwatch.Elapsed()
 				}
-				m.log.send(entry{a: m.activities[m.selected], typ: m.state, t: time.Now(), d: m.stopwatch.Elapsed()})
+				m = m.logStint(m.state, time.Now())
 				m.stopwatch, cmd = m.stopwatch.Update(m.stopwatch.Reset()()) // force a reset - note a tea.Cmd needs to be turned into a tea.Msg
 				return m.switchTo(ready), cmd
 			case key.Matches(msg, m.keymap.work):
 				m.tally[m.selected][1] += m.stopwatch.Elapsed()
-				m.log.send(entry{a: m.activities[m.selected], typ: m.state, t: time.Now(), d: m.stopwatch.Elapsed()})
-				m.bank -= m.stopwatch.Elapsed()
+				m = m.logStint(m.state, time.Now())
+				m.bank = m.technique.rested(m.bank, m.stopwatch.Elapsed())
 				return m.switchTo(working), m.stopwatch.Reset()
 			case key.Matches(msg, m.keymap.rest):
 				m.tally[m.selected][0] += m.stopwatch.Elapsed()
-				m.log.send(entry{a: m.activities[m.selected], typ: m.state, t: time.Now(), d: m.stopwatch.Elapsed()})
-				m.bank = m.bank + m.stopwatch.Elapsed()/3
+				m = m.logStint(m.state, time.Now())
+				m.bank = m.technique.worked(m.bank, m.stopwatch.Elapsed())
 				return m.switchTo(resting), m.stopwatch.Reset()
 			}
 		}
@@ -435,6 +864,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m.switchTo(weekly), nil
 			case key.Matches(msg, m.keymap.year):
 				return m.switchTo(yearly), nil
+			case key.Matches(msg, m.keymap.rollup):
+				m.rollup = !m.rollup
+				return m.switchTo(m.state), nil
 			case key.Matches(msg, m.keymap.shrink):
 				m.log.shrink(m.activities)
 				m.keymap.shrink.SetEnabled(false)
@@ -473,50 +905,66 @@ func main() {
 	ti.Focus()
 	ti.CharLimit = 156
 	ti.Width = 20
+	si := textinput.New()
+	si.Prompt = "/ "
+	si.CharLimit = 156
+	si.Width = 20
 	lg, err := newlogger()
 	if err != nil {
 		fmt.Printf("something went wrong: %v", err)
 		os.Exit(1)
 	}
-	act, sel, week, weekPrev, year, yearPrev := lg.refresh()
-
-	wcols := []table.Column{
-		{Title: "Type", Width: 5},
-		{Title: "Mon", Width: 6},
-		{Title: "Tues", Width: 6},
-		{Title: "Weds", Width: 6},
-		{Title: "Thurs", Width: 6},
-		{Title: "Fri", Width: 6},
-		{Title: "Sat", Width: 6},
-		{Title: "Sun", Width: 6},
-		{Title: "Total", Width: 6},
+	if lg.read(); lg.bad != nil {
+		fmt.Printf("warning: %v; entries after it are left out and the log won't be rewritten until it's fixed\n", lg.bad)
 	}
-
-	ycols := []table.Column{
-		{Title: "Type", Width: 5},
-		{Title: "Jan", Width: 7},
-		{Title: "Feb", Width: 7},
-		{Title: "Mar", Width: 7},
-		{Title: "Apr", Width: 7},
-		{Title: "May", Width: 7},
-		{Title: "June", Width: 7},
-		{Title: "July", Width: 7},
-		{Title: "Aug", Width: 7},
-		{Title: "Sept", Width: 7},
-		{Title: "Oct", Width: 7},
-		{Title: "Nov", Width: 7},
-		{Title: "Dec", Width: 7},
-		{Title: "Total", Width: 8},
+	if err := loadConfig(); err != nil {
+		fmt.Printf("bad config file: %v\n", err)
+		os.Exit(1)
+	}
+	if len(os.Args) > 1 {
+		if err := command(lg, os.Args[1:]); err != nil {
+			fmt.Printf("something went wrong: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	tq, err := newTechnique(cfg.Technique)
+	if err != nil {
+		fmt.Printf("bad config file: %v\n", err)
+		os.Exit(1)
+	}
+	nt, err := newNotifier(cfg.Notify)
+	if err != nil {
+		fmt.Printf("bad config file: %v\n", err)
+		os.Exit(1)
+	}
+	if _, err := cfg.Schedule.plan(lg); err != nil {
+		fmt.Printf("bad config file: %v\n", err)
+		os.Exit(1)
 	}
+	act, sel, week, weekPrev, year, yearPrev := lg.refresh()
 
 	wt := table.New(
-		table.WithColumns(wcols),
+		table.WithColumns(weekColumns()),
 		table.WithFocused(true),
 		table.WithHeight(3),
 	)
 
 	yt := table.New(
-		table.WithColumns(ycols),
+		table.WithColumns(yearColumns()),
+		table.WithFocused(true),
+		table.WithHeight(3),
+	)
+
+	et := table.New(
+		table.WithColumns([]table.Column{
+			{Title: "Start", Width: 7},
+			{Title: "End", Width: 7},
+			{Title: "Activity", Width: 20},
+			{Title: "Type", Width: 5},
+			{Title: "Time", Width: 6},
+			{Title: "Note", Width: 30},
+		}),
 		table.WithFocused(true),
 		table.WithHeight(3),
 	)
@@ -534,13 +982,21 @@ func main() {
 
 	wt.SetStyles(s)
 	yt.SetStyles(s)
+	et.SetStyles(s)
 
 	m := model{
 		log:        lg,
 		activities: act,
 		tally:      lg.tally(act),
 		selected:   sel,
+		technique:  tq,
+		notifier:   nt,
+		hooks:      &hooks{cmds: cfg.Hooks},
+		earlier:    lg.earlier(),
+		tallied:    startOfDay(time.Now()),
+		spark:      lg.sparkDays(cfg.sparkWeeks()),
 		textInput:  ti,
+		search:     si,
 		stopwatch:  stopwatch.NewWithInterval(time.Second),
 		keymap: keymap{
 			work: key.NewBinding(
@@ -563,6 +1019,66 @@ func main() {
 				key.WithKeys("d"),
 				key.WithHelp("d", "delete"),
 			),
+			edit: key.NewBinding(
+				key.WithKeys("e"),
+				key.WithHelp("e", "edit details"),
+			),
+			rename: key.NewBinding(
+				key.WithKeys("m"),
+				key.WithHelp("m", "rename/merge"),
+			),
+			archive: key.NewBinding(
+				key.WithKeys("x"),
+				key.WithHelp("x", "archive/restore"),
+			),
+			showArchived: key.NewBinding(
+				key.WithKeys("v"),
+				key.WithHelp("v", "show archived"),
+			),
+			search: key.NewBinding(
+				key.WithKeys("/"),
+				key.WithHelp("/", "filter"),
+			),
+			order: key.NewBinding(
+				key.WithKeys("o"),
+				key.WithHelp("o", "recent first"),
+			),
+			pin: key.NewBinding(
+				key.WithKeys("f"),
+				key.WithHelp("f", "pin"),
+			),
+			quick: key.NewBinding(
+				key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"),
+				key.WithHelp("1-9", "quick select"),
+			),
+			fold: key.NewBinding(
+				key.WithKeys("left", "right", "h", "l"),
+				key.WithHelp("←/→", "fold"),
+			),
+			note: key.NewBinding(
+				key.WithKeys("n"),
+				key.WithHelp("n", "note"),
+			),
+			list: key.NewBinding(
+				key.WithKeys("e"),
+				key.WithHelp("e", "entries"),
+			),
+			dashboard: key.NewBinding(
+				key.WithKeys("t"),
+				key.WithHelp("t", "today"),
+			),
+			stats: key.NewBinding(
+				key.WithKeys("i"),
+				key.WithHelp("i", "stats"),
+			),
+			timeline: key.NewBinding(
+				key.WithKeys("g"),
+				key.WithHelp("g", "timeline"),
+			),
+			scroll: key.NewBinding(
+				key.WithKeys("left", "right", "h", "l"),
+				key.WithHelp("←/→", "scroll days"),
+			),
 			stop: key.NewBinding(
 				key.WithKeys("s"),
 				key.WithHelp("s", "stop"),
@@ -579,6 +1095,10 @@ func main() {
 				key.WithKeys("s"),
 				key.WithHelp("s", "shrink the log"),
 			),
+			rollup: key.NewBinding(
+				key.WithKeys("u"),
+				key.WithHelp("u", "roll up sub-activities"),
+			),
 			next: key.NewBinding(
 				key.WithKeys("n"),
 				key.WithHelp("n", "next"),
@@ -592,13 +1112,15 @@ func main() {
 				key.WithHelp("q", "quit"),
 			),
 		},
-		help:     help.New(),
-		week:     week,
-		weekPrev: weekPrev,
-		year:     year,
-		yearPrev: yearPrev,
-		weekTbl:  wt,
-		yearTbl:  yt,
+		help:      help.New(),
+		collapsed: make(map[string]bool),
+		week:      week,
+		weekPrev:  weekPrev,
+		year:      year,
+		yearPrev:  yearPrev,
+		weekTbl:   wt,
+		yearTbl:   yt,
+		entryTbl:  et,
 	}
 	m = m.switchTo(ready)
 	if _, err := tea.NewProgram(m).Run(); err != nil {
@@ -606,3 +1128,45 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// weekColumns are the columns of the weekly report
+func weekColumns() []table.Column {
+	cols := []table.Column{
+		{Title: "Type", Width: 5},
+		{Title: "Mon", Width: 7},
+		{Title: "Tues", Width: 7},
+		{Title: "Weds", Width: 7},
+		{Title: "Thurs", Width: 7},
+		{Title: "Fri", Width: 7},
+		{Title: "Sat", Width: 7},
+		{Title: "Sun", Width: 7},
+		{Title: "Total", Width: 9},
+		{Title: "vs prev", Width: 8},
+		{Title: "vs last yr", Width: 10},
+	}
+	if cfg.Goals.weekly() {
+		cols = append(cols, table.Column{Title: "Goal", Width: 13}, table.Column{Title: "+/-", Width: 7})
+	}
+	return cols
+}
+
+// yearColumns are the columns of the yearly report
+func yearColumns() []table.Column {
+	return []table.Column{
+		{Title: "Type", Width: 5},
+		{Title: "Jan", Width: 8},
+		{Title: "Feb", Width: 8},
+		{Title: "Mar", Width: 8},
+		{Title: "Apr", Width: 8},
+		{Title: "May", Width: 8},
+		{Title: "June", Width: 8},
+		{Title: "July", Width: 8},
+		{Title: "Aug", Width: 8},
+		{Title: "Sept", Width: 8},
+		{Title: "Oct", Width: 8},
+		{Title: "Nov", Width: 8},
+		{Title: "Dec", Width: 7},
+		{Title: "Total", Width: 8},
+		{Title: "vs prev", Width: 8},
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var metaname = "activities.json"
+
+// meta describes an activity. It is kept in a sidecar file alongside the log, keyed by activity.
+type meta struct {
+	Client      string   `json:"client,omitempty"`
+	Project     string   `json:"project,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Colour      string   `json:"colour,omitempty"`   // a lipgloss colour e.g. "#FF5F87" or "205"
+	Billable    *bool    `json:"billable,omitempty"` // unset bills the activity if it has a rate, false never bills it
+	Description string   `json:"description,omitempty"`
+	Pinned      bool     `json:"pinned,omitempty"` // shown first in the activity selector
+}
+
+func loadMeta() (map[string]meta, error) {
+	ret := make(map[string]meta)
+	byt, err := os.ReadFile(filepath.Join(logpath, metaname))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ret, nil
+		}
+		return ret, err
+	}
+	return ret, json.Unmarshal(byt, &ret)
+}
+
+func (l *logger) saveMeta() error {
+	byt, err := json.MarshalIndent(l.meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(logpath, metaname), byt, 0644)
+}
+
+func (l *logger) setMeta(activity string, md meta) error {
+	if md.empty() {
+		delete(l.meta, activity)
+	} else {
+		l.meta[activity] = md
+	}
+	return l.saveMeta()
+}
+
+func (md meta) empty() bool {
+	return md.Client == "" && md.Project == "" && len(md.Tags) == 0 && md.Colour == "" && md.Billable == nil && md.Description == "" && !md.Pinned
+}
+
+// render styles an activity name with its colour, if it has one
+func (md meta) render(activity string) string {
+	if md.Colour == "" {
+		return activity
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(md.Colour)).Render(activity)
+}
+
+// filter matches activities against the client, project, tag and billable report options
+type filter struct {
+	client   string
+	project  string
+	tag      string
+	billable bool
+}
+
+func (f filter) match(activity string, md meta) bool {
+	if f.client != "" && !strings.EqualFold(f.client, md.Client) {
+		return false
+	}
+	if f.project != "" && !strings.EqualFold(f.project, md.Project) {
+		return false
+	}
+	if f.tag != "" && !slices.ContainsFunc(md.Tags, func(t string) bool { return strings.EqualFold(f.tag, t) }) {
+		return false
+	}
+	if f.billable {
+		_, ok := cfg.rate(activity, md)
+		return ok
+	}
+	return true
+}
+
+// groups returns the names an activity is grouped under for a report: by client, project or tag.
+// Tagged activities may fall into more than one group.
+func (md meta) groups(activity, by string) ([]string, error) {
+	var ret []string
+	switch by {
+	case "", "activity":
+		ret = []string{activity}
+	case "tree": // roll sub-activities up into their parents
+		ret = append([]string{cleanPath(activity)}, ancestors(activity)...)
+	case "client":
+		ret = []string{md.Client}
+	case "project":
+		ret = []string{md.Project}
+	case "tag":
+		ret = md.Tags
+	default:
+		return nil, fmt.Errorf("can't group by %q, expecting client, project or tag", by)
+	}
+	if len(ret) == 0 || (len(ret) == 1 && ret[0] == "") {
+		return []string{"(none)"}, nil
+	}
+	return ret, nil
+}
+
+var metaFields = []string{"Client", "Project", "Tags", "Colour", "Billable", "Description"}
+
+func newForm(md meta) []textinput.Model {
+	vals := []string{md.Client, md.Project, strings.Join(md.Tags, ", "), md.Colour, "", md.Description}
+	if md.Billable != nil {
+		vals[4] = "no"
+		if *md.Billable {
+			vals[4] = "yes"
+		}
+	}
+	ret := make([]textinput.Model, len(metaFields))
+	for i := range ret {
+		ret[i] = textinput.New()
+		ret[i].Prompt = fmt.Sprintf("%-13s", metaFields[i]+":")
+		ret[i].CharLimit = 156
+		ret[i].Width = 40
+		ret[i].SetValue(vals[i])
+	}
+	ret[2].Placeholder = "comma separated"
+	ret[3].Placeholder = `e.g. "#FF5F87" or "205"`
+	ret[4].Placeholder = "yes or no"
+	ret[0].Focus()
+	return ret
+}
+
+func formMeta(form []textinput.Model) meta {
+	md := meta{
+		Client:      strings.TrimSpace(form[0].Value()),
+		Project:     strings.TrimSpace(form[1].Value()),
+		Colour:      strings.TrimSpace(form[3].Value()),
+		Description: strings.TrimSpace(form[5].Value()),
+	}
+	for _, t := range strings.Split(form[2].Value(), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			md.Tags = append(md.Tags, t)
+		}
+	}
+	switch strings.ToLower(strings.TrimSpace(form[4].Value())) {
+	case "y", "yes", "true":
+		b := true
+		md.Billable = &b
+	case "n", "no", "false":
+		b := false
+		md.Billable = &b
+	}
+	return md
+}
+
+func (m model) formView() string {
+	s := fmt.Sprintf("Edit %s:\n", m.activities[m.rows[m.cursor].idx])
+	for _, f := range m.form {
+		s += f.View() + "\n"
+	}
+	return s + "\n" + style.Render("(tab) next field • (enter) save • (esc) cancel")
+}
+
+func (m model) updateForm(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.Type {
+		case tea.KeyEsc:
+			return m.switchTo(selecting), nil
+		case tea.KeyCtrlC:
+			return m.switchTo(quitting), tea.Quit
+		case tea.KeyEnter:
+			a := m.activities[m.rows[m.cursor].idx]
+			md := formMeta(m.form)
+			md.Pinned = m.log.meta[a].Pinned
+			m.log.setMeta(a, md)
+			return m.switchTo(selecting), nil
+		case tea.KeyTab, tea.KeyDown, tea.KeyShiftTab, tea.KeyUp:
+			m.form[m.focus].Blur()
+			if msg.Type == tea.KeyTab || msg.Type == tea.KeyDown {
+				m.focus = (m.focus + 1) % len(m.form)
+			} else {
+				m.focus = (m.focus + len(m.form) - 1) % len(m.form)
+			}
+			return m, m.form[m.focus].Focus()
+		}
+	}
+	var cmd tea.Cmd
+	m.form[m.focus], cmd = m.form[m.focus].Update(msg)
+	return m, cmd
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type notifyConfig struct {
+	Sinks   []string `json:"sinks"` // bell, osc9, osc777 or notify-send
+	Command []string `json:"command"`
+	Exec    string   `json:"exec"`
+	Events  []string `json:"events"` // all of them if empty
+	Long    duration `json:"long"`
+}
+
+type sink interface {
+	notify(n notification) error
+}
+
+type notification struct {
+	event    string
+	message  string
+	activity string
+	state    string // working or resting
+}
+
+// printed through the program so they don't land mid redraw
+type escape string
+
+const (
+	bell   escape = "\a"
+	osc9   escape = "\x1b]9;%s\a"
+	osc777 escape = "\x1b]777;notify;clockon;%s\a"
+)
+
+func (e escape) sequence(n notification) string {
+	if e == bell {
+		return string(e)
+	}
+	return fmt.Sprintf(string(e), n.message)
+}
+
+type program []string
+
+func (p program) notify(n notification) error {
+	return exec.Command(p[0], append(p[1:], n.message)...).Run()
+}
+
+type shell string
+
+func (s shell) notify(n notification) error {
+	return run(string(s), []string{
+		"CLOCKON_EVENT=" + n.event,
+		"CLOCKON_MESSAGE=" + n.message,
+		"CLOCKON_ACTIVITY=" + n.activity,
+		"CLOCKON_STATE=" + n.state,
+	}, nil)
+}
+
+func run(cmd string, env []string, stdin []byte) error {
+	c := exec.Command("sh", "-c", cmd)
+	c.Env = append(os.Environ(), env...)
+	if stdin != nil {
+		c.Stdin = bytes.NewReader(stdin)
+	}
+	return c.Run()
+}
+
+type notifier struct {
+	sinks   []sink
+	escapes []escape
+	events  []string
+	long    time.Duration
+	last    time.Duration // to tell when a new stint starts
+	fired   map[string]bool
+	goaled  string
+}
+
+func newNotifier(nc notifyConfig) (*notifier, error) {
+	nt := &notifier{events: nc.Events, long: time.Duration(nc.Long), fired: make(map[string]bool)}
+	for _, s := range nc.Sinks {
+		switch s {
+		case "bell":
+			nt.escapes = append(nt.escapes, bell)
+		case "osc9":
+			nt.escapes = append(nt.escapes, osc9)
+		case "osc777":
+			nt.escapes = append(nt.escapes, osc777)
+		case "notify-send":
+			nt.sinks = append(nt.sinks, program{"notify-send", "clockon"})
+		default:
+			return nil, fmt.Errorf("unknown notification sink %q, expecting bell, osc9, osc777 or notify-send", s)
+		}
+	}
+	if len(nc.Command) > 0 {
+		nt.sinks = append(nt.sinks, program(nc.Command))
+	}
+	if nc.Exec != "" {
+		nt.sinks = append(nt.sinks, shell(nc.Exec))
+	}
+	for _, e := range nc.Events {
+		if e != "target" && e != "long" && e != "goal" {
+			return nil, fmt.Errorf("unknown notification event %q, expecting target, long or goal", e)
+		}
+	}
+	return nt, nil
+}
+
+func (nt *notifier) wants(event string) bool {
+	return len(nt.events) == 0 || slices.Contains(nt.events, event)
+}
+
+// best effort, so failures are dropped
+func (nt *notifier) send(ns []notification) tea.Cmd {
+	if len(ns) == 0 {
+		return nil
+	}
+	var cmds []tea.Cmd
+	if len(nt.escapes) > 0 {
+		lines := make([]string, len(ns))
+		for i, n := range ns {
+			for _, e := range nt.escapes {
+				lines[i] += e.sequence(n)
+			}
+			lines[i] += n.message
+		}
+		cmds = append(cmds, tea.Println(strings.Join(lines, "\n")))
+	}
+	if sinks := nt.sinks; len(sinks) > 0 {
+		cmds = append(cmds, func() tea.Msg {
+			for _, n := range ns {
+				for _, s := range sinks {
+					s.notify(n)
+				}
+			}
+			return nil
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m model) running() (state, bool) {
+	switch m.state {
+	case working, resting:
+		return m.state, true
+	case noting, selecting, editing, renaming, dashboard, timeline:
+		if m.switching() {
+			return m.statePrev, true
+		}
+	}
+	return ready, false
+}
+
+func (m model) checkNotify() tea.Cmd {
+	nt := m.notifier
+	s, ok := m.running()
+	if nt == nil || !ok {
+		return nil
+	}
+	elapsed := m.stopwatch.Elapsed()
+	if elapsed < nt.last {
+		clear(nt.fired)
+	}
+	nt.last = elapsed
+	a := m.activities[m.selected]
+	var ns []notification
+	fire := func(event, msg string) {
+		if nt.fired[event] || !nt.wants(event) {
+			return
+		}
+		nt.fired[event] = true
+		ns = append(ns, notification{event: event, message: msg, activity: a, state: s.String()})
+	}
+	if t, ok := m.technique.target(s, m.bank); ok && elapsed >= t {
+		if s == working {
+			fire("target", fmt.Sprintf("Time for a break from %s", a))
+		} else {
+			fire("target", fmt.Sprintf("Break's over, back to %s", a))
+		}
+	}
+	if s == working && nt.long > 0 && elapsed >= nt.long {
+		fire("long", fmt.Sprintf("You've been working on %s for %s", a, elapsed.Round(time.Second)))
+	}
+	if goal := time.Duration(cfg.Goals.Total.Day.Min); goal > 0 && nt.wants("goal") {
+		var worked time.Duration
+		for _, t := range m.tally {
+			worked += t[0]
+		}
+		if s == working {
+			worked += elapsed
+		}
+		today := time.Now().Format(time.DateOnly)
+		if worked >= goal && nt.goaled != today {
+			nt.goaled = today
+			ns = append(ns, notification{event: "goal", message: fmt.Sprintf("Daily goal of %s reached", fmtDuration(goal)), activity: a, state: s.String()})
+		}
+	}
+	return nt.send(ns)
+}
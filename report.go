@@ -0,0 +1,200 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+type reportRow struct {
+	name    string           // activity or group
+	d       [2]time.Duration // work, break
+	billed  time.Duration
+	amounts map[string]float64 // keyed by currency
+}
+
+// rangeReport totals work, break and billed time for each activity in the stints given,
+// or for each client, project or tag if grouped
+func rangeReport(es []entry, md map[string]meta, by string) ([]reportRow, error) {
+	idx := make(map[string]int)
+	var ret []reportRow
+	rows := func(a string) ([]int, error) {
+		gs, err := md[a].groups(a, by)
+		if err != nil {
+			return nil, err
+		}
+		is := make([]int, len(gs))
+		for j, g := range gs {
+			i, ok := idx[g]
+			if !ok {
+				i = len(ret)
+				idx[g] = i
+				ret = append(ret, reportRow{name: g, amounts: make(map[string]float64)})
+			}
+			is[j] = i
+		}
+		return is, nil
+	}
+	for _, e := range es {
+		is, err := rows(e.a)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range is {
+			ret[i].d[e.typ-working] += e.d
+		}
+	}
+	for _, r := range timesheet(es, md) {
+		is, _ := rows(r.activity)
+		rt, billable := cfg.rate(r.activity, md[r.activity])
+		for _, i := range is {
+			ret[i].billed += r.billed
+			if billable {
+				ret[i].amounts[rt.Currency] += rt.amount(r.billed)
+			}
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].name < ret[j].name })
+	return ret, nil
+}
+
+func fmtAmounts(amounts map[string]float64) string {
+	curs := make([]string, 0, len(amounts))
+	for k := range amounts {
+		curs = append(curs, k)
+	}
+	sort.Strings(curs)
+	strs := make([]string, len(curs))
+	for i, c := range curs {
+		strs[i] = strings.TrimSpace(fmt.Sprintf("%.2f %s", amounts[c], c))
+	}
+	return strings.Join(strs, ", ")
+}
+
+func reportCmd(lg *logger, args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	from := fs.String("from", "", "first day of the report (YYYY-MM-DD), defaults to the start of this week")
+	to := fs.String("to", "", "last day of the report (YYYY-MM-DD), defaults to today")
+	activity := fs.String("activity", "", "only include this activity")
+	rollup := fs.Bool("rollup", false, "include the sub-activities of the activity")
+	var f filter
+	fs.StringVar(&f.client, "client", "", "only include activities for this client")
+	fs.StringVar(&f.project, "project", "", "only include activities for this project")
+	fs.StringVar(&f.tag, "tag", "", "only include activities with this tag")
+	fs.BoolVar(&f.billable, "billable", false, "only include activities that are charged, through their own rate or the \"*\" rate")
+	by := fs.String("group", "", "total by client, project or tag rather than activity")
+	compare := fs.Bool("compare", false, "compare work with the range before and the same range a year earlier")
+	asHTML := fs.Bool("html", false, "write an HTML page with tables and charts for the range and each week and year in it")
+	out := fs.String("o", "", "file to write (defaults to stdout)")
+	fs.Parse(args)
+	if *from == "" {
+		*from = time.Now().AddDate(0, 0, -dayIndex(time.Now())).Format(time.DateOnly)
+	}
+	start, end, err := parseRange(*from, *to)
+	if err != nil {
+		return err
+	}
+	if *rollup && *by == "" {
+		*by = "tree"
+	}
+	report := func(start, end time.Time) ([]reportRow, reportRow, error) {
+		es := slices.DeleteFunc(lg.stints(*activity, *rollup, start, end), func(e entry) bool { return !f.match(e.a, lg.meta[e.a]) })
+		rows, err := rangeReport(es, lg.meta, *by)
+		if err != nil {
+			return nil, reportRow{}, err
+		}
+		if *by == "tree" && *activity != "" {
+			rows = slices.DeleteFunc(rows, func(r reportRow) bool { return !within(r.name, *activity) })
+		}
+		total := rows
+		if *by != "" {
+			total, _ = rangeReport(es, lg.meta, "") // tagged activities can be in more than one group
+		}
+		return rows, sumRows(total), nil
+	}
+	rows, total, err := report(start, end)
+	if err != nil {
+		return err
+	}
+	var cmps []comparison
+	if *compare {
+		prev, lastYr := earlierRange(start, end)
+		for i, r := range [][2]time.Time{prev, lastYr} {
+			rs, t, err := report(r[0], r[1])
+			if err != nil {
+				return err
+			}
+			cmps = append(cmps, newComparison([]string{"vs prev", "vs last yr"}[i], r, rs, t))
+		}
+	}
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if *asHTML {
+		return writeHTML(w, lg, *activity, *rollup, rows, total, start, end, cmps...)
+	}
+	if err := writeReport(w, rows, total, start, end, cmps...); err != nil {
+		return err
+	}
+	return writeLeave(w, lg.leaves(start, end))
+}
+
+func fmtRange(r [2]time.Time) string {
+	return r[0].Format(time.DateOnly) + " to " + r[1].AddDate(0, 0, -1).Format(time.DateOnly)
+}
+
+func sumRows(rows []reportRow) reportRow {
+	ret := reportRow{name: "Total", amounts: make(map[string]float64)}
+	for _, r := range rows {
+		ret.d[0] += r.d[0]
+		ret.d[1] += r.d[1]
+		ret.billed += r.billed
+		for k, v := range r.amounts {
+			ret.amounts[k] += v
+		}
+	}
+	return ret
+}
+
+// reportTable lays out the report as a header and rows of cells, with a column for the change in work since each earlier report compared
+func reportTable(rows []reportRow, total reportRow, cmps []comparison) ([]string, [][]string) {
+	head := []string{"", "Work", "Break", "Total", "Billed", "Amount"}
+	for _, c := range cmps {
+		head = append(head, c.title)
+	}
+	body := make([][]string, 0, len(rows)+1)
+	for _, r := range append(rows, total) {
+		cells := []string{r.name, fmtDuration(r.d[0]), fmtDuration(r.d[1]), fmtDuration(r.d[0] + r.d[1]), fmtDuration(r.billed), fmtAmounts(r.amounts)}
+		for _, c := range cmps {
+			cells = append(cells, signed(r.d[0]-c.work[r.name]))
+		}
+		body = append(body, cells)
+	}
+	return head, body
+}
+
+func writeReport(w io.Writer, rows []reportRow, total reportRow, start, end time.Time, cmps ...comparison) error {
+	fmt.Fprintf(w, "Report %s\n", fmtRange([2]time.Time{start, end}))
+	for _, c := range cmps {
+		fmt.Fprintf(w, "%s: %s\n", c.title, fmtRange(c.span))
+	}
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	head, body := reportTable(rows, total, cmps)
+	for _, cells := range append([][]string{head}, body...) {
+		fmt.Fprintln(tw, strings.Join(cells, "\t")+"\t")
+	}
+	return tw.Flush()
+}
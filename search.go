@@ -0,0 +1,163 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fuzzy matches a pattern against an activity name as a case-insensitive subsequence.
+// Higher scores go to runs of consecutive characters and matches at the start of words.
+func fuzzy(pattern, s string) (int, bool) {
+	p := []rune(strings.ToLower(pattern))
+	r := []rune(strings.ToLower(s))
+	var score, pi, gap int
+	for i := 0; i < len(r) && pi < len(p); i++ {
+		if r[i] != p[pi] {
+			gap++
+			continue
+		}
+		score += 10
+		if i == 0 || !unicode.IsLetter(r[i-1]) && !unicode.IsDigit(r[i-1]) {
+			score += 8
+		}
+		if gap == 0 && pi > 0 {
+			score += 5
+		}
+		score -= min(gap, 5)
+		gap = 0
+		pi++
+	}
+	return score, pi == len(p)
+}
+
+// flat returns rows for activities in the order given
+func flat(activities []string, idxs []int) []node {
+	ret := make([]node, len(idxs))
+	for i, idx := range idxs {
+		ret[i] = node{path: cleanPath(activities[idx]), name: activities[idx], idx: idx}
+	}
+	return ret
+}
+
+// visible returns the indexes of the activities that aren't hidden
+func (m model) visible(hidden map[string]bool) []int {
+	ret := make([]int, 0, len(m.activities))
+	for i, a := range m.activities {
+		if !hidden[a] {
+			ret = append(ret, i)
+		}
+	}
+	return ret
+}
+
+// filtered returns the rows matching the search, best match first
+func (m model) filtered(hidden map[string]bool) []node {
+	scores := make(map[int]int)
+	idxs := slices.DeleteFunc(m.visible(hidden), func(i int) bool {
+		score, ok := fuzzy(m.search.Value(), m.activities[i])
+		scores[i] = score
+		return !ok
+	})
+	slices.SortStableFunc(idxs, func(a, b int) int {
+		if scores[a] != scores[b] {
+			return scores[b] - scores[a]
+		}
+		return m.log.used[m.activities[b]].Compare(m.log.used[m.activities[a]])
+	})
+	return flat(m.activities, idxs)
+}
+
+// recentRows returns the rows with the most recently used activities first
+func (m model) recentRows(hidden map[string]bool) []node {
+	idxs := m.visible(hidden)
+	slices.SortStableFunc(idxs, func(a, b int) int {
+		return m.log.used[m.activities[b]].Compare(m.log.used[m.activities[a]])
+	})
+	return flat(m.activities, idxs)
+}
+
+// pinned returns rows for favourite activities, to show above the others
+func (m model) pinned(hidden map[string]bool) []node {
+	ret := flat(m.activities, slices.DeleteFunc(m.visible(hidden), func(i int) bool {
+		return !m.log.meta[m.activities[i]].Pinned
+	}))
+	for i := range ret {
+		ret[i].pinned = true
+	}
+	return ret
+}
+
+// quick returns the rows that can be chosen with the number keys
+func (m model) quick() []int {
+	ret := make([]int, 0, 9)
+	for i, n := range m.rows {
+		if len(ret) == 9 {
+			break
+		}
+		if n.idx >= 0 {
+			ret = append(ret, i)
+		}
+	}
+	return ret
+}
+
+func (m model) togglePin() model {
+	if len(m.rows) == 0 || m.rows[m.cursor].idx < 0 {
+		return m
+	}
+	n := m.rows[m.cursor]
+	a := m.activities[n.idx]
+	md := m.log.meta[a]
+	md.Pinned = !md.Pinned
+	m.log.setMeta(a, md)
+	return m.refreshRows(n.path)
+}
+
+func (m model) updateSearch(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.Type {
+		case tea.KeyCtrlC:
+			return m.switchTo(quitting), tea.Quit
+		case tea.KeyEsc:
+			m.searching = false
+			m.search.Reset()
+			m.search.Blur()
+			if len(m.rows) == 0 {
+				return m.refreshRows(""), nil
+			}
+			return m.refreshRows(m.rows[m.cursor].path), nil
+		case tea.KeyEnter:
+			m.searching = false
+			m.search.Blur()
+			return m.choose()
+		case tea.KeyUp:
+			if m.cursor > 0 {
+				m.cursor--
+			}
+			return m, nil
+		case tea.KeyDown:
+			if m.cursor < len(m.rows)-1 {
+				m.cursor++
+			}
+			return m, nil
+		}
+	}
+	m.search, cmd = m.search.Update(msg)
+	m.cursor = 0
+	return m.refreshRows(""), cmd
+}
+
+// lastUsed records when each activity was last selected or worked on
+func lastUsed(e entry, used map[string]time.Time) {
+	if e.typ != selecting && e.typ != working && e.typ != resting {
+		return
+	}
+	if t := e.t.Add(e.d); t.After(used[e.a]) {
+		used[e.a] = t
+	}
+}
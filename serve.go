@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The server keeps its own clock, logging stints as they end so they're in the log straight away.
+// It shouldn't run the clock at the same time as the terminal UI, as neither sees the other's stints until restarted.
+
+//go:embed serve.html
+var serveHTML []byte
+
+type server struct {
+	mu        sync.Mutex
+	log       *logger
+	technique technique
+	activity  string
+	state     state // ready, working or resting
+	start     time.Time
+	bank      time.Duration
+}
+
+type stateJSON struct {
+	State    string     `json:"state"`
+	Activity string     `json:"activity"`
+	Start    *time.Time `json:"start,omitempty"`
+	Elapsed  float64    `json:"elapsed"` // in seconds
+	Bank     float64    `json:"bank"`    // in seconds
+	Status   string     `json:"status"`  // the technique's progress
+	Work     float64    `json:"work"`    // worked today, in seconds
+	Break    float64    `json:"break"`   // on breaks today, in seconds
+}
+
+type activityJSON struct {
+	Name     string `json:"name"`
+	Archived bool   `json:"archived"`
+	Selected bool   `json:"selected"`
+}
+
+type reportJSON struct {
+	Name    string             `json:"name"`
+	Work    float64            `json:"work"` // in seconds
+	Break   float64            `json:"break"`
+	Billed  float64            `json:"billed"`
+	Amounts map[string]float64 `json:"amounts,omitempty"`
+}
+
+func toReportJSON(r reportRow) reportJSON {
+	return reportJSON{Name: r.name, Work: r.d[0].Seconds(), Break: r.d[1].Seconds(), Billed: r.billed.Seconds(), Amounts: r.amounts}
+}
+
+// clockRequest is the optional body of the work, break and stop requests.
+// The note is for the stint that's ending.
+type clockRequest struct {
+	Activity string `json:"activity"`
+	Note     string `json:"note"`
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+// loopback reports whether a host is this machine
+func loopback(host string) bool {
+	ip := net.ParseIP(host)
+	return host == "localhost" || (ip != nil && ip.IsLoopback())
+}
+
+// local turns away requests from pages on other sites, and from other names for this machine to guard against DNS rebinding
+func local(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if !loopback(host) {
+			writeError(w, http.StatusForbidden, errors.New("requests must be made to localhost"))
+			return
+		}
+		if o := r.Header.Get("Origin"); o != "" && o != "http://"+r.Host {
+			writeError(w, http.StatusForbidden, errors.New("cross-origin requests aren't allowed"))
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// save sends entries to the log and writes them out at once
+func (l *logger) save(es ...entry) error {
+	for _, e := range es {
+		if err := e.valid(); err != nil {
+			return err
+		}
+	}
+	l.read()
+	for _, e := range es {
+		l.send(e)
+	}
+	err := l.flush()
+	if err == nil {
+		l.buffered = append(l.buffered, l.session...)
+	}
+	l.session = l.session[:0]
+	return err
+}
+
+// end logs the running stint, if there is one, and settles the bank
+// If the log can't be written the clock keeps running.
+func (s *server) end(now time.Time, note string) error {
+	if !clocked(s.state) {
+		return nil
+	}
+	d := now.Sub(s.start)
+	if err := s.log.save(entry{a: s.activity, typ: s.state, t: now, d: d, n: note}); err != nil {
+		return err
+	}
+	if s.state == working {
+		s.bank = s.technique.worked(s.bank, d)
+	} else {
+		s.bank = s.technique.rested(s.bank, d)
+	}
+	s.state = ready
+	return nil
+}
+
+func (s *server) status() stateJSON {
+	now := time.Now()
+	st := stateJSON{State: s.state.String(), Activity: s.activity, Bank: s.bank.Seconds(), Status: s.technique.status(s.bank)}
+	for _, e := range s.log.day(now) {
+		if e.typ == working {
+			st.Work += e.d.Seconds()
+		} else if e.typ == resting {
+			st.Break += e.d.Seconds()
+		}
+	}
+	if clocked(s.state) {
+		start := s.start
+		st.Start, st.Elapsed = &start, now.Sub(start).Seconds()
+		if s.state == working {
+			st.Work += st.Elapsed
+		} else {
+			st.Break += st.Elapsed
+		}
+	}
+	return st
+}
+
+func (s *server) getState(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusOK, s.status())
+}
+
+// clock returns a handler that ends any running stint and starts the clock in a new state, or stops it if ready
+func (s *server) clock(to state) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req clockRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+		req.Activity = strings.TrimSpace(req.Activity)
+		if err := validName(req.Activity); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		now := time.Now()
+		if to != ready && req.Activity != "" && req.Activity != s.activity {
+			if err := s.end(now, req.Note); err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+			req.Note = ""
+			s.activity = req.Activity
+			if err := s.log.save(entry{a: s.activity, typ: selecting, t: now}); err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+		if to != ready && s.activity == "" {
+			writeError(w, http.StatusBadRequest, errors.New("no activity selected"))
+			return
+		}
+		if s.state != to {
+			if err := s.end(now, req.Note); err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+			if to != ready {
+				s.state, s.start = to, now
+			}
+		}
+		writeJSON(w, http.StatusOK, s.status())
+	}
+}
+
+func (s *server) getActivities(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acts, _, _, _, _, _ := s.log.refresh()
+	ret := make([]activityJSON, 0, len(acts))
+	for _, a := range acts {
+		ret = append(ret, activityJSON{Name: a, Archived: s.log.archived[a], Selected: a == s.activity})
+	}
+	writeJSON(w, http.StatusOK, ret)
+}
+
+// getReport totals time per activity over a range, taking the report command's from, to, activity, rollup and group options
+func (s *server) getReport(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	from := q.Get("from")
+	if from == "" {
+		from = startOfWeek(time.Now()).Format(time.DateOnly)
+	}
+	start, end, err := parseRange(from, q.Get("to"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	activity, by := q.Get("activity"), q.Get("group")
+	rollup, _ := strconv.ParseBool(q.Get("rollup"))
+	if rollup && by == "" {
+		by = "tree"
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	es := s.log.stints(activity, rollup, start, end)
+	rows, err := rangeReport(es, s.log.meta, by)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if by == "tree" && activity != "" {
+		rows = slices.DeleteFunc(rows, func(r reportRow) bool { return !within(r.name, activity) })
+	}
+	total := rows
+	if by != "" {
+		total, _ = rangeReport(es, s.log.meta, "")
+	}
+	ret := struct {
+		From  string       `json:"from"`
+		To    string       `json:"to"`
+		Rows  []reportJSON `json:"rows"`
+		Total reportJSON   `json:"total"`
+	}{From: start.Format(time.DateOnly), To: end.AddDate(0, 0, -1).Format(time.DateOnly), Rows: make([]reportJSON, len(rows)), Total: toReportJSON(sumRows(total))}
+	for i, row := range rows {
+		ret.Rows[i] = toReportJSON(row)
+	}
+	writeJSON(w, http.StatusOK, ret)
+}
+
+// serveCmd serves the clock over HTTP e.g. `clockon serve -addr localhost:4747`.
+// It only listens on the loopback interface as there's no authentication.
+func serveCmd(lg *logger, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:4747", "address to listen on, which must be on localhost")
+	fs.Parse(args)
+	host, _, err := net.SplitHostPort(*addr)
+	if err != nil {
+		return err
+	}
+	if !loopback(host) {
+		return fmt.Errorf("serve only listens on localhost, not %s", host)
+	}
+	tq, err := newTechnique(cfg.Technique)
+	if err != nil {
+		return err
+	}
+	acts, sel, _, _, _, _ := lg.refresh()
+	s := &server{log: lg, technique: tq}
+	if len(acts) > 0 {
+		s.activity = acts[sel]
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(serveHTML)
+	})
+	mux.HandleFunc("GET /api/state", s.getState)
+	mux.HandleFunc("POST /api/work", s.clock(working))
+	mux.HandleFunc("POST /api/break", s.clock(resting))
+	mux.HandleFunc("POST /api/stop", s.clock(ready))
+	mux.HandleFunc("GET /api/activities", s.getActivities)
+	mux.HandleFunc("GET /api/report", s.getReport)
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Handler: local(mux)}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		srv.Shutdown(context.Background())
+	}()
+	fmt.Printf("serving on http://%s (ctrl+c to stop)\n", ln.Addr())
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.end(time.Now(), "") // log the stint on the clock before quitting
+}
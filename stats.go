@@ -0,0 +1,207 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type weekStat struct {
+	week   [2]int
+	d      [2]time.Duration
+	stints int
+}
+
+type stats struct {
+	stints  int
+	breaks  int
+	d       [2]time.Duration
+	longest entry
+	days    int
+	weeks   []weekStat
+	streak  int
+	best    int
+	goal    time.Duration
+	hours   [24]time.Duration
+}
+
+func dailyGoal(activity string) time.Duration {
+	if n, ok := cfg.Goals.goalFor(activity); ok && cfg.Goals.Activities[n].Day.Min > 0 {
+		return time.Duration(cfg.Goals.Activities[n].Day.Min)
+	}
+	return time.Duration(cfg.Goals.Total.Day.Min)
+}
+
+func byHour(hours *[24]time.Duration, e entry) {
+	t, end := e.t.Local(), e.t.Add(e.d).Local()
+	for t.Before(end) {
+		next := t.Truncate(time.Hour).Add(time.Hour)
+		if next.After(end) {
+			next = end
+		}
+		hours[t.Hour()] += next.Sub(t)
+		t = next
+	}
+}
+
+func calcStats(es []entry, p plan, goal time.Duration, start, end time.Time) stats {
+	s := stats{goal: goal}
+	dd := daily(es)
+	widx := make(map[[2]int]int)
+	for _, e := range es {
+		y, w := e.t.Local().ISOWeek()
+		i, ok := widx[[2]int{y, w}]
+		if !ok {
+			i = len(s.weeks)
+			widx[[2]int{y, w}] = i
+			s.weeks = append(s.weeks, weekStat{week: [2]int{y, w}})
+		}
+		s.weeks[i].d[e.typ-working] += e.d
+		s.d[e.typ-working] += e.d
+		if e.typ == resting {
+			s.breaks++
+			continue
+		}
+		s.stints++
+		s.weeks[i].stints++
+		if e.d > s.longest.d {
+			s.longest = e
+		}
+		byHour(&s.hours, e)
+	}
+	s.days = len(dd)
+	if len(es) == 0 {
+		return s
+	}
+	if first := startOfDay(es[0].t.Local()); start.Before(first) {
+		start = first
+	}
+	var run int
+	for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+		w := dd[d.Format(time.DateOnly)]
+		switch {
+		case w > 0 && w >= goal:
+			run++
+			s.best = max(s.best, run)
+		case p.expected(d) == 0 || p.taken(d) >= p.expected(d): // days off don't break a streak
+		case sameDay(d, time.Now()): // nor does today, while there's still time
+		default:
+			run = 0
+		}
+	}
+	s.streak = run
+	return s
+}
+
+func ratio(d [2]time.Duration) string {
+	if d[1] == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f:1", d[0].Hours()/d[1].Hours())
+}
+
+func writeStats(w io.Writer, s stats) error {
+	if s.stints == 0 {
+		_, err := fmt.Fprintln(w, "No work in this range")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Work\t%s in %d stints over %d days\t\n", fmtDuration(s.d[0]), s.stints, s.days)
+	fmt.Fprintf(tw, "Breaks\t%s in %d breaks\t\n", fmtDuration(s.d[1]), s.breaks)
+	fmt.Fprintf(tw, "Average stint\t%s\t\n", fmtDuration(s.d[0]/time.Duration(s.stints)))
+	fmt.Fprintf(tw, "Stints per day\t%.1f\t\n", float64(s.stints)/float64(s.days))
+	fmt.Fprintf(tw, "Longest stint\t%s on %s, %s\t\n", fmtDuration(s.longest.d), s.longest.a, s.longest.t.Local().Format("Mon 2 Jan 2006 3:04PM"))
+	fmt.Fprintf(tw, "Work:break\t%s\t\n", ratio(s.d))
+	var top int
+	for h, d := range s.hours {
+		if d > s.hours[top] {
+			top = h
+		}
+	}
+	fmt.Fprintf(tw, "Most productive hour\t%s to %s (%s)\t\n", hourLabel(top), hourLabel(top+1), fmtDuration(s.hours[top]))
+	streak := "days in a row with work"
+	if s.goal > 0 {
+		streak = "days in a row meeting the goal of " + fmtDuration(s.goal)
+	}
+	fmt.Fprintf(tw, "Streak\t%d %s, best %d\t\n", s.streak, streak, s.best)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\nBy hour  %s\n         %s\n", sparkline(s.hours[:]), hourAxis())
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Week\tWork\tBreak\tWork:break\tStints\t")
+	for _, ws := range s.weeks {
+		fmt.Fprintf(tw, "%d-W%02d\t%s\t%s\t%s\t%d\t\n", ws.week[0], ws.week[1], fmtDuration(ws.d[0]), fmtDuration(ws.d[1]), ratio(ws.d), ws.stints)
+	}
+	return tw.Flush()
+}
+
+func hourLabel(h int) string {
+	return time.Date(0, 1, 1, h%24, 0, 0, 0, time.Local).Format("3PM")
+}
+
+func hourAxis() string {
+	var b strings.Builder
+	for h := 0; h < 24; h += 6 {
+		fmt.Fprintf(&b, "%-6d", h)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func statsCmd(lg *logger, args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	from := fs.String("from", "", "first day (YYYY-MM-DD), defaults to the start of the log")
+	to := fs.String("to", "", "last day (YYYY-MM-DD), defaults to today")
+	activity := fs.String("activity", "", "only include this activity")
+	rollup := fs.Bool("rollup", false, "include the sub-activities of the activity")
+	fs.Parse(args)
+	start, end, err := parseRange(*from, *to)
+	if err != nil {
+		return err
+	}
+	p, err := cfg.Schedule.plan(lg)
+	if err != nil {
+		return err
+	}
+	s := calcStats(lg.stints(*activity, *rollup, start, end), p, dailyGoal(*activity), start, end)
+	return writeStats(os.Stdout, s)
+}
+
+func (m model) lastWeeks() stats {
+	end := startOfDay(time.Now()).AddDate(0, 0, 1)
+	start := end.AddDate(0, 0, -28)
+	p, _ := cfg.Schedule.plan(m.log) // the schedule was checked on start up
+	a := m.activities[m.selected]
+	return calcStats(m.log.stints(a, m.rollup, start, end), p, dailyGoal(a), start, end)
+}
+
+func (m model) statsView() string {
+	var b strings.Builder
+	hdr := fmt.Sprintf("Stats for %s%s over the last 4 weeks:", m.activities[m.selected], m.rollupView())
+	b.WriteString(hstyle.Render(hdr) + "\n")
+	writeStats(&b, m.stats)
+	return b.String() + m.helpView()
+}
+
+func (m model) updateStats(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, m.keymap.quit):
+			return m.switchTo(quitting), tea.Quit
+		case key.Matches(msg, m.keymap.rollup):
+			m.rollup = !m.rollup
+			m.stats = m.lastWeeks()
+		case key.Matches(msg, m.keymap.stats) || msg.Type == tea.KeyEnter || msg.Type == tea.KeySpace || msg.Type == tea.KeyEsc:
+			return m.switchTo(m.statePrev), nil
+		}
+	}
+	return m, nil
+}
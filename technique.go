@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// technique decides how work and breaks trade off. Third Time is the default.
+type technique interface {
+	// worked returns the bank after a work stint of d ends in a break or switch
+	worked(bank, d time.Duration) time.Duration
+	// rested returns the bank after a break of d ends in work or a switch
+	rested(bank, d time.Duration) time.Duration
+	// target returns how long a stint in the working or resting state should run, and false if it can run as long as you like
+	target(s state, bank time.Duration) (time.Duration, bool)
+	// status describes the technique's progress for the status line
+	status(bank time.Duration) string
+	// prompt reports whether to tell you to switch once a target is reached
+	prompt() bool
+}
+
+// thirdTime banks a third of the time worked to spend on breaks, which count down the bank
+type thirdTime struct {
+	prompts bool
+}
+
+func (thirdTime) worked(bank, d time.Duration) time.Duration { return bank + d/3 }
+
+func (thirdTime) rested(bank, d time.Duration) time.Duration { return bank - d }
+
+func (thirdTime) target(s state, bank time.Duration) (time.Duration, bool) {
+	return bank, s == resting
+}
+
+func (tt thirdTime) prompt() bool { return tt.prompts }
+
+func (thirdTime) status(bank time.Duration) string {
+	return fmt.Sprintf("Bank: %s", bank.Round(time.Second))
+}
+
+// interval runs fixed length work stints and breaks, with a longer break after every so many stints e.g. Pomodoro
+type interval struct {
+	name      string
+	work      time.Duration
+	rest      time.Duration
+	longRest  time.Duration
+	every     int
+	completed int
+}
+
+func (iv *interval) worked(bank, d time.Duration) time.Duration {
+	if d >= iv.work {
+		iv.completed++
+	}
+	return bank
+}
+
+func (iv *interval) rested(bank, d time.Duration) time.Duration { return bank }
+
+func (iv *interval) target(s state, bank time.Duration) (time.Duration, bool) {
+	if s == working {
+		return iv.work, true
+	}
+	if iv.every > 0 && iv.longRest > 0 && iv.completed > 0 && iv.completed%iv.every == 0 {
+		return iv.longRest, true
+	}
+	return iv.rest, true
+}
+
+func (iv *interval) prompt() bool { return true }
+
+func (iv *interval) status(bank time.Duration) string {
+	return fmt.Sprintf("%s: %d done", iv.name, iv.completed)
+}
+
+type techniqueConfig struct {
+	Name      string   `json:"name"`       // thirdtime (the default), pomodoro or interval
+	Work      duration `json:"work"`       // length of a work stint for an interval e.g. "52m"
+	Break     duration `json:"break"`      // length of a break for an interval e.g. "17m"
+	LongBreak duration `json:"long_break"` // length of the longer break
+	Every     int      `json:"every"`      // take the longer break after this many work stints
+	Prompt    bool     `json:"prompt"`     // for Third Time, tell you to get back to work when the bank is spent
+}
+
+func newTechnique(tc techniqueConfig) (technique, error) {
+	switch tc.Name {
+	case "", "thirdtime":
+		return thirdTime{prompts: tc.Prompt}, nil
+	case "pomodoro":
+		iv := &interval{name: "Pomodoros", work: 25 * time.Minute, rest: 5 * time.Minute, longRest: 15 * time.Minute, every: 4}
+		if tc.Work > 0 {
+			iv.work = time.Duration(tc.Work)
+		}
+		if tc.Break > 0 {
+			iv.rest = time.Duration(tc.Break)
+		}
+		if tc.LongBreak > 0 {
+			iv.longRest = time.Duration(tc.LongBreak)
+		}
+		if tc.Every > 0 {
+			iv.every = tc.Every
+		}
+		return iv, nil
+	case "interval":
+		if tc.Work <= 0 || tc.Break <= 0 {
+			return nil, fmt.Errorf("an interval technique needs work and break durations")
+		}
+		return &interval{name: "Stints", work: time.Duration(tc.Work), rest: time.Duration(tc.Break), longRest: time.Duration(tc.LongBreak), every: tc.Every}, nil
+	}
+	return nil, fmt.Errorf("unknown technique %q, expecting thirdtime, pomodoro or interval", tc.Name)
+}
+
+// clockView shows the running stint, counting down if the technique sets a target
+func (m model) clockView() string {
+	elapsed := m.stopwatch.Elapsed()
+	verb, prep, next := "Working", "on", "Time for a break! Hit 'b'"
+	if m.state == resting {
+		verb, prep, next = "Breaking", "from", "Break's over! Hit 'w'"
+	}
+	t, ok := m.technique.target(m.state, m.bank)
+	if !ok {
+		return fmt.Sprintf("%s for %s %s %s", verb, elapsed, prep, m.activities[m.selected])
+	}
+	left := t - elapsed
+	if left > 0 {
+		return fmt.Sprintf("%s %s %s, %s left", verb, prep, m.activities[m.selected], left.Round(time.Second))
+	}
+	str := fmt.Sprintf("%s %s %s, %s", verb, prep, m.activities[m.selected], alertStyle.Render(fmt.Sprintf("over by %s", (-left).Round(time.Second))))
+	if m.technique.prompt() {
+		str += "\n" + alertStyle.Render(next)
+	}
+	return str
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// palette colours activities that haven't been given a colour of their own
+var palette = []string{"39", "214", "78", "141", "203", "45", "220", "171"}
+
+// colour returns an activity's colour, or its nearest parent's, or one picked from the palette
+func (l *logger) colour(a string) lipgloss.Color {
+	for _, p := range append([]string{a}, ancestors(a)...) {
+		if c := l.meta[p].Colour; c != "" {
+			return lipgloss.Color(c)
+		}
+	}
+	h := fnv.New32a()
+	h.Write([]byte(a))
+	return lipgloss.Color(palette[h.Sum32()%uint32(len(palette))])
+}
+
+const timelineWidth = 60
+
+// timelineStints returns the stints in the seven days shown in the timeline, including the one on the clock
+func (m model) timelineStints() []entry {
+	end := m.day.AddDate(0, 0, 7)
+	es := m.log.stints("", false, m.day, end)
+	if s, ok := m.running(); ok && time.Now().Before(end) {
+		es = append(es, entry{a: m.activities[m.selected], typ: s, t: time.Now().Add(-m.stopwatch.Elapsed()), d: m.stopwatch.Elapsed()})
+	}
+	return es
+}
+
+// hourSpan finds the whole hours of the day the stints fall within, or office hours if there aren't any
+func hourSpan(es []entry) (int, int) {
+	if len(es) == 0 {
+		return 9, 17
+	}
+	first, last := 24.0, 0.0
+	for _, e := range es {
+		s := e.t.Local()
+		f := s.Sub(startOfDay(s)).Hours()
+		first = min(first, f)
+		last = max(last, min(f+e.d.Hours(), 24))
+	}
+	return int(math.Floor(first)), max(int(math.Ceil(last)), int(math.Floor(first))+1)
+}
+
+// dayBar draws a day's stints across the hours, in the colours of their activities
+func (m model) dayBar(es []entry, day time.Time, h0, h1 int) (string, time.Duration) {
+	start := day.Add(time.Duration(h0) * time.Hour)
+	span := time.Duration(h1-h0) * time.Hour
+	cells := make([]*entry, timelineWidth)
+	var worked time.Duration
+	for i := range es {
+		e := &es[i]
+		if !sameDay(e.t.Local(), day) {
+			continue
+		}
+		if e.typ == working {
+			worked += e.d
+		}
+		from := int(float64(e.t.Sub(start)) / float64(span) * timelineWidth)
+		to := int(float64(e.t.Add(e.d).Sub(start)) / float64(span) * timelineWidth)
+		for c := max(from, 0); c <= min(to, timelineWidth-1); c++ {
+			if cells[c] == nil || cells[c].typ != working || e.typ == working { // work shows over a break sharing the cell
+				cells[c] = e
+			}
+		}
+	}
+	var b strings.Builder
+	for _, e := range cells {
+		switch {
+		case e == nil:
+			b.WriteString(style.Render("·"))
+		case e.typ == working:
+			b.WriteString(lipgloss.NewStyle().Foreground(m.log.colour(e.a)).Render("█"))
+		default:
+			b.WriteString(lipgloss.NewStyle().Foreground(m.log.colour(e.a)).Render("░"))
+		}
+	}
+	return b.String(), worked
+}
+
+// hourScale labels the hours across the timeline, spaced so they don't run together
+func hourScale(h0, h1 int) string {
+	line := []rune(strings.Repeat(" ", timelineWidth+5))
+	per := float64(timelineWidth) / float64(h1-h0)
+	step := max(1, int(math.Ceil(5/per)))
+	for h := h0; h < h1; h += step {
+		lbl := time.Date(0, 1, 1, h, 0, 0, 0, time.Local).Format("3PM")
+		copy(line[int(float64(h-h0)*per):], []rune(lbl))
+	}
+	return strings.TrimRight(string(line), " ")
+}
+
+// timelineView shows a week of days as bars across the hours, with work solid and breaks shaded
+func (m model) timelineView() string {
+	es := m.timelineStints()
+	h0, h1 := hourSpan(es)
+	var b strings.Builder
+	hdr := fmt.Sprintf("Timeline for %s to %s:", m.day.Format("Mon 2 Jan"), m.day.AddDate(0, 0, 6).Format("Mon 2 Jan 2006"))
+	b.WriteString(hstyle.Render(hdr) + "\n")
+	fmt.Fprintf(&b, "%-10s %s\n", "", hourScale(h0, h1))
+	today := time.Now()
+	for i := range 7 {
+		day := m.day.AddDate(0, 0, i)
+		bar, worked := m.dayBar(es, day, h0, h1)
+		lbl := fmt.Sprintf("%-10s", day.Format("Mon 2 Jan"))
+		if sameDay(day, today) {
+			lbl = hstyle.Render(lbl)
+		}
+		fmt.Fprintf(&b, "%s %s %s\n", lbl, bar, style.Render(fmtDuration(worked)))
+	}
+	// legend of the activities shown
+	var seen []string
+	for _, e := range es {
+		if !slices.Contains(seen, e.a) {
+			seen = append(seen, e.a)
+		}
+	}
+	if len(seen) > 0 {
+		b.WriteString("\n")
+	}
+	for _, a := range seen {
+		fmt.Fprintf(&b, "%s %s\n", lipgloss.NewStyle().Foreground(m.log.colour(a)).Render("█"), a)
+	}
+	return b.String() + m.helpView()
+}
+
+func (m model) updateTimeline(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, m.keymap.quit):
+			if m.switching() {
+				m = m.logStint(m.statePrev, time.Now())
+			}
+			return m.switchTo(quitting), tea.Quit
+		case key.Matches(msg, m.keymap.next):
+			m.day = m.day.AddDate(0, 0, 7)
+		case key.Matches(msg, m.keymap.prev):
+			m.day = m.day.AddDate(0, 0, -7)
+		case key.Matches(msg, m.keymap.scroll):
+			if msg.String() == "left" || msg.String() == "h" {
+				m.day = m.day.AddDate(0, 0, -1)
+			} else {
+				m.day = m.day.AddDate(0, 0, 1)
+			}
+		case key.Matches(msg, m.keymap.timeline) || msg.Type == tea.KeyEnter || msg.Type == tea.KeySpace || msg.Type == tea.KeyEsc:
+			return m.switchTo(m.statePrev), nil
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// rounding is a billing policy e.g. {"increment": "6m", "mode": "up", "per": "day", "minimum": "15m"}
+type rounding struct {
+	Increment duration `json:"increment"` // round to multiples of this
+	Mode      string   `json:"mode"`      // up, down or nearest (the default)
+	Per       string   `json:"per"`       // round each stint or the total for each day (the default)
+	Minimum   duration `json:"minimum"`   // bill at least this much for each stint or day worked
+}
+
+// rate is an hourly billing rate e.g. {"hourly": 150, "currency": "AUD"}
+type rate struct {
+	Hourly   float64 `json:"hourly"`
+	Currency string  `json:"currency"`
+	Breaks   bool    `json:"breaks"` // bill break time as well as work
+}
+
+// rate returns the rate for an activity, or the "*" rate if it has none of its own.
+// Activities whose details mark them not billable have no rate.
+func (c config) rate(activity string, md meta) (rate, bool) {
+	if md.Billable != nil && !*md.Billable {
+		return rate{}, false
+	}
+	if r, ok := c.Rates[activity]; ok {
+		return r, true
+	}
+	r, ok := c.Rates["*"]
+	return r, ok
+}
+
+// billable returns the work, and break if billed, from a work/break pair
+func (r rate) billable(d [2]time.Duration) time.Duration {
+	if r.Breaks {
+		return d[0] + d[1]
+	}
+	return d[0]
+}
+
+func (r rate) amount(d time.Duration) float64 {
+	return d.Hours() * r.Hourly
+}
+
+func fmtAmount(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
+
+func (c config) rounding(activity string) rounding {
+	if r, ok := c.Rounding[activity]; ok {
+		return r
+	}
+	return c.Rounding["*"]
+}
+
+func (r rounding) round(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	if inc := time.Duration(r.Increment); inc > 0 {
+		switch r.Mode {
+		case "up":
+			if rem := d % inc; rem > 0 {
+				d += inc - rem
+			}
+		case "down":
+			d = d.Truncate(inc)
+		default:
+			d = d.Round(inc)
+		}
+	}
+	if min := time.Duration(r.Minimum); d < min {
+		d = min
+	}
+	return d
+}
+
+// bill applies the policy to the stints worked in a single day
+func (r rounding) bill(stints []time.Duration) time.Duration {
+	var ret time.Duration
+	if r.Per == "stint" {
+		for _, d := range stints {
+			ret += r.round(d)
+		}
+		return ret
+	}
+	for _, d := range stints {
+		ret += d
+	}
+	return r.round(ret)
+}
+
+type sheetRow struct {
+	day      time.Time
+	activity string
+	raw      time.Duration
+	billed   time.Duration
+	notes    []string
+}
+
+// timesheet totals the billable time for each activity for each day in the range.
+// Breaks are only included if the activity's rate says so.
+func timesheet(es []entry, md map[string]meta) []sheetRow {
+	type key struct {
+		day string
+		a   string
+	}
+	stints := make(map[key][]time.Duration)
+	notes := make(map[key][]string)
+	for _, e := range es {
+		if e.typ != working {
+			if r, _ := cfg.rate(e.a, md[e.a]); e.typ != resting || !r.Breaks {
+				continue
+			}
+		}
+		k := key{e.t.Format(time.DateOnly), e.a}
+		stints[k] = append(stints[k], e.d)
+		if e.n != "" {
+			notes[k] = append(notes[k], e.n)
+		}
+	}
+	ret := make([]sheetRow, 0, len(stints))
+	for k, v := range stints {
+		day, _ := time.ParseInLocation(time.DateOnly, k.day, time.Local)
+		row := sheetRow{day: day, activity: k.a, billed: cfg.rounding(k.a).bill(v), notes: notes[k]}
+		for _, d := range v {
+			row.raw += d
+		}
+		ret = append(ret, row)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].day.Equal(ret[j].day) {
+			return ret[i].activity < ret[j].activity
+		}
+		return ret[i].day.Before(ret[j].day)
+	})
+	return ret
+}
+
+func hours(d time.Duration) string {
+	return strconv.FormatFloat(d.Hours(), 'f', 2, 64)
+}
+
+func timesheetCmd(lg *logger, args []string) error {
+	fs := flag.NewFlagSet("timesheet", flag.ExitOnError)
+	from := fs.String("from", "", "first day of the timesheet (YYYY-MM-DD), defaults to the start of this week")
+	to := fs.String("to", "", "last day of the timesheet (YYYY-MM-DD), defaults to today")
+	activity := fs.String("activity", "", "only include this activity")
+	rollup := fs.Bool("rollup", false, "include the sub-activities of the activity")
+	asCSV := fs.Bool("csv", false, "write CSV rather than a printable table")
+	out := fs.String("o", "", "file to write (defaults to stdout)")
+	fs.Parse(args)
+	if *from == "" {
+		*from = time.Now().AddDate(0, 0, -dayIndex(time.Now())).Format(time.DateOnly)
+	}
+	start, end, err := parseRange(*from, *to)
+	if err != nil {
+		return err
+	}
+	rows := timesheet(lg.stints(*activity, *rollup, start, end), lg.meta)
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if *asCSV {
+		return writeTimesheetCSV(w, rows, lg.meta)
+	}
+	return writeTimesheet(w, rows, lg.meta, start, end)
+}
+
+func writeTimesheetCSV(w io.Writer, rows []sheetRow, md map[string]meta) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"date", "activity", "raw", "billed", "billed hours", "amount", "currency", "notes"})
+	for _, r := range rows {
+		amt, cur := amountCols(r.activity, md[r.activity], r.billed)
+		cw.Write([]string{r.day.Format(time.DateOnly), r.activity, fmtDuration(r.raw), fmtDuration(r.billed), hours(r.billed), amt, cur, strings.Join(r.notes, "; ")})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// amountCols returns the amount and currency for billed time, or blanks if the activity has no rate
+func amountCols(activity string, md meta, billed time.Duration) (string, string) {
+	r, ok := cfg.rate(activity, md)
+	if !ok {
+		return "", ""
+	}
+	return strconv.FormatFloat(r.amount(billed), 'f', 2, 64), r.Currency
+}
+
+func writeTimesheet(w io.Writer, rows []sheetRow, md map[string]meta, start, end time.Time) error {
+	fmt.Fprintf(w, "Timesheet %s to %s\n\n", start.Format(time.DateOnly), end.AddDate(0, 0, -1).Format(time.DateOnly))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Date\tActivity\tRaw\tBilled\tHours\tAmount\tNotes\t")
+	totals := make(map[string][2]time.Duration)
+	var names []string
+	for _, r := range rows {
+		amt, cur := amountCols(r.activity, md[r.activity], r.billed)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s %s\t%s\t\n", r.day.Format(time.DateOnly), r.activity, fmtDuration(r.raw), fmtDuration(r.billed), hours(r.billed), amt, cur, strings.Join(r.notes, "; "))
+		t, ok := totals[r.activity]
+		if !ok {
+			names = append(names, r.activity)
+		}
+		totals[r.activity] = [2]time.Duration{t[0] + r.raw, t[1] + r.billed}
+	}
+	fmt.Fprintln(tw, "\t\t\t\t\t\t\t")
+	sort.Strings(names)
+	var grand [2]time.Duration
+	for _, n := range names {
+		t := totals[n]
+		amt, cur := amountCols(n, md[n], t[1])
+		fmt.Fprintf(tw, "Total\t%s\t%s\t%s\t%s\t%s %s\t\t\n", n, fmtDuration(t[0]), fmtDuration(t[1]), hours(t[1]), amt, cur)
+		grand[0] += t[0]
+		grand[1] += t[1]
+	}
+	fmt.Fprintf(tw, "Total\t\t%s\t%s\t%s\t\t\t\n", fmtDuration(grand[0]), fmtDuration(grand[1]), hours(grand[1]))
+	return tw.Flush()
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRound(t *testing.T) {
+	six := duration(6 * time.Minute)
+	for _, tc := range []struct {
+		r    rounding
+		in   time.Duration
+		want time.Duration
+	}{
+		{rounding{}, 17 * time.Minute, 17 * time.Minute},
+		{rounding{Increment: six, Mode: "up"}, 13 * time.Minute, 18 * time.Minute},
+		{rounding{Increment: six, Mode: "up"}, 12 * time.Minute, 12 * time.Minute},
+		{rounding{Increment: six, Mode: "down"}, 17 * time.Minute, 12 * time.Minute},
+		{rounding{Increment: six}, 14 * time.Minute, 12 * time.Minute},
+		{rounding{Increment: six}, 16 * time.Minute, 18 * time.Minute},
+		{rounding{Increment: six, Minimum: duration(15 * time.Minute)}, 4 * time.Minute, 15 * time.Minute},
+		{rounding{Minimum: duration(15 * time.Minute)}, 0, 0},
+	} {
+		if got := tc.r.round(tc.in); got != tc.want {
+			t.Errorf("%+v round(%s) = %s, want %s", tc.r, tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestBill(t *testing.T) {
+	stints := []time.Duration{4 * time.Minute, 7 * time.Minute}
+	up := rounding{Increment: duration(6 * time.Minute), Mode: "up"}
+	if got := up.bill(stints); got != 12*time.Minute {
+		t.Errorf("per day bill = %s, want 12m", got)
+	}
+	up.Per = "stint"
+	if got := up.bill(stints); got != 18*time.Minute {
+		t.Errorf("per stint bill = %s, want 18m", got)
+	}
+	up.Minimum = duration(10 * time.Minute)
+	if got := up.bill(stints); got != 22*time.Minute {
+		t.Errorf("per stint bill with a minimum = %s, want 22m", got)
+	}
+}
+
+func TestRateNotBillable(t *testing.T) {
+	defer func(c config) { cfg = c }(cfg)
+	cfg.Rates = map[string]rate{"*": {Hourly: 100, Currency: "AUD"}}
+	no := false
+	if _, ok := cfg.rate("Web", meta{}); !ok {
+		t.Error("expected the \"*\" rate for an activity without details")
+	}
+	if _, ok := cfg.rate("Web", meta{Billable: &no}); ok {
+		t.Error("expected no rate for an activity marked not billable")
+	}
+	md := map[string]meta{"Admin": {Billable: &no}}
+	if amt, _ := amountCols("Admin", md["Admin"], time.Hour); amt != "" {
+		t.Errorf("not billable activity charged %s", amt)
+	}
+	f := filter{billable: true}
+	if !f.match("Web", meta{}) || f.match("Admin", md["Admin"]) {
+		t.Error("expected the billable filter to follow the rates")
+	}
+}
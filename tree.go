@@ -0,0 +1,292 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Activities can be nested by separating their parts with slashes e.g. "Client A / Feature X / Code review"
+
+func splitPath(a string) []string {
+	segs := strings.Split(a, "/")
+	for i := range segs {
+		segs[i] = strings.TrimSpace(segs[i])
+	}
+	return segs
+}
+
+func cleanPath(a string) string {
+	return strings.Join(splitPath(a), "/")
+}
+
+// within reports whether activity a is the parent activity or one of its descendants
+func within(a, parent string) bool {
+	a, parent = cleanPath(a), cleanPath(parent)
+	return a == parent || strings.HasPrefix(a, parent+"/")
+}
+
+// matches reports whether a matches an activity filter, with a blank filter matching everything
+func matches(a, activity string, rollup bool) bool {
+	if activity == "" || a == activity {
+		return true
+	}
+	return rollup && within(a, activity)
+}
+
+// ancestors returns the parent paths of an activity, nearest first
+func ancestors(a string) []string {
+	segs := splitPath(a)
+	ret := make([]string, 0, len(segs)-1)
+	for i := len(segs) - 1; i > 0; i-- {
+		ret = append(ret, strings.Join(segs[:i], "/"))
+	}
+	return ret
+}
+
+// node is a row in the activity tree
+type node struct {
+	path   string // cleaned path
+	name   string // last part of the path
+	depth  int
+	idx    int // index into the activities, or -1 if this node is only a parent
+	parent bool
+	pinned bool
+}
+
+// tree returns the visible rows of the activity tree, skipping hidden activities and descendants of collapsed nodes
+func tree(activities []string, collapsed, hidden map[string]bool) []node {
+	nodes := make(map[string]*node)
+	var extra []node
+	for i, a := range activities {
+		if hidden[a] {
+			continue
+		}
+		p, segs := cleanPath(a), splitPath(a)
+		switch n, ok := nodes[p]; {
+		case !ok:
+			nodes[p] = &node{path: p, name: segs[len(segs)-1], depth: len(segs) - 1, idx: i}
+		case n.idx < 0:
+			n.idx = i
+		default: // the name differs from another only in spacing, so give it its own row under its full name
+			extra = append(extra, node{path: p, name: a, depth: len(segs) - 1, idx: i})
+		}
+		for _, anc := range ancestors(a) {
+			if n, ok := nodes[anc]; ok {
+				n.parent = true
+				continue
+			}
+			segs := splitPath(anc)
+			nodes[anc] = &node{path: anc, name: segs[len(segs)-1], depth: len(segs) - 1, idx: -1, parent: true}
+		}
+	}
+	ret := make([]node, 0, len(nodes)+len(extra))
+	for _, n := range nodes {
+		ret = append(ret, *n)
+	}
+	ret = append(ret, extra...)
+	slices.SortStableFunc(ret, func(a, b node) int { return slices.Compare(splitPath(a.path), splitPath(b.path)) })
+	return slices.DeleteFunc(ret, func(n node) bool {
+		for _, anc := range ancestors(n.path) {
+			if collapsed[anc] {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (m model) treeView() string {
+	var s string
+	if m.searching || m.search.Value() != "" {
+		s += m.search.View() + "\n"
+	}
+	quick := m.quick()
+	for i, n := range m.rows {
+		cursor, checked, num := " ", " ", " "
+		if m.cursor == i {
+			cursor = ">"
+			checked = "x"
+		}
+		if q := slices.Index(quick, i); q >= 0 && m.state == selecting {
+			num = strconv.Itoa(q + 1)
+		}
+		box := fmt.Sprintf("[%s]", checked)
+		if n.idx < 0 {
+			box = "   "
+		}
+		fold := " "
+		if n.pinned {
+			fold = "★"
+		}
+		if n.parent {
+			fold = "▾"
+			if m.collapsed[n.path] {
+				fold = "▸"
+			}
+		}
+		name := n.name
+		if n.idx >= 0 {
+			name = m.log.meta[m.activities[n.idx]].render(name)
+			if m.log.archived[m.activities[n.idx]] {
+				name += style.Render(" (archived)")
+			}
+		}
+		// Render the row
+		s += fmt.Sprintf("%s %s %s %s%s %s\n", cursor, num, box, strings.Repeat("  ", n.depth), fold, name)
+	}
+	return s
+}
+
+// refreshRows rebuilds the tree, keeping the cursor on the given path
+func (m model) refreshRows(path string) model {
+	hidden := m.log.archived
+	if m.showArchived {
+		hidden = nil
+	}
+	switch {
+	case m.search.Value() != "":
+		m.rows = m.filtered(hidden)
+	case m.recent:
+		m.rows = append(m.pinned(hidden), slices.DeleteFunc(m.recentRows(hidden), func(n node) bool {
+			return m.log.meta[m.activities[n.idx]].Pinned
+		})...)
+	default: // pinned activities are listed first rather than in the tree
+		pinned := m.pinned(hidden)
+		skip := maps.Clone(hidden)
+		if skip == nil {
+			skip = make(map[string]bool)
+		}
+		for _, n := range pinned {
+			skip[m.activities[n.idx]] = true
+		}
+		m.rows = append(pinned, tree(m.activities, m.collapsed, skip)...)
+	}
+	for i, n := range m.rows {
+		if n.path == path {
+			m.cursor = i
+			return m
+		}
+	}
+	m.cursor = max(min(m.cursor, len(m.rows)-1), 0) // the path is gone so stay put
+	return m
+}
+
+// expandTo opens the ancestors of the selected activity and puts the cursor on it
+func (m model) expandTo(idx int) model {
+	if idx < 0 || idx >= len(m.activities) {
+		return m.refreshRows("")
+	}
+	for _, anc := range ancestors(m.activities[idx]) {
+		delete(m.collapsed, anc)
+	}
+	return m.refreshRows(cleanPath(m.activities[idx]))
+}
+
+func (m model) collapse() model {
+	if len(m.rows) == 0 {
+		return m
+	}
+	n := m.rows[m.cursor]
+	if n.parent && !m.collapsed[n.path] {
+		m.collapsed[n.path] = true
+		return m.refreshRows(n.path)
+	}
+	if anc := ancestors(n.path); len(anc) > 0 {
+		return m.refreshRows(anc[0])
+	}
+	return m
+}
+
+func (m model) expand() model {
+	if len(m.rows) == 0 {
+		return m
+	}
+	n := m.rows[m.cursor]
+	delete(m.collapsed, n.path)
+	return m.refreshRows(n.path)
+}
+
+// hasChildren reports whether the selected activity has sub-activities
+func (m model) hasChildren() bool {
+	for i, a := range m.activities {
+		if i != m.selected && within(a, m.activities[m.selected]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m model) rollupView() string {
+	if m.rollup && m.hasChildren() {
+		return " and sub-activities"
+	}
+	return ""
+}
+
+// choose selects or removes the activity under the cursor, or opens or closes it if it is only a parent
+func (m model) choose() (tea.Model, tea.Cmd) {
+	if len(m.rows) == 0 {
+		return m, nil
+	}
+	n := m.rows[m.cursor]
+	if n.idx < 0 { // only a parent so open or close it
+		if m.collapsed[n.path] {
+			return m.expand(), nil
+		}
+		return m.collapse(), nil
+	}
+	if m.state == selecting && m.switching() {
+		return m.switchActivity(n.idx)
+	}
+	// remove or select
+	if m.selected != n.idx {
+		m.selected = n.idx
+	}
+	a := m.activities[m.selected]
+	m.log.send(entry{a: a, typ: m.state, t: time.Now()})
+	if m.state == selecting {
+		m.hook("select", selecting, false)
+		if m.statePrev == weekly || m.statePrev == yearly {
+			return m.switchTo(m.statePrev), nil
+		}
+		return m.switchTo(ready), nil
+	}
+	m.activities, m.selected, m.week, m.weekPrev, m.year, m.yearPrev = m.log.refresh()
+	m.weekNxt = [2]int{}
+	m.yearNxt = 0
+	m.tally = m.log.tally(m.activities)
+	m.hookOn(a, "delete", removing, false)
+	return m.switchTo(removing), nil
+}
+
+// switching reports whether the activity is being changed while the clock is running
+func (m model) switching() bool {
+	return m.statePrev == working || m.statePrev == resting
+}
+
+// switchActivity closes the running stint and opens a new one on another activity at the same instant
+func (m model) switchActivity(idx int) (tea.Model, tea.Cmd) {
+	typ := m.statePrev
+	if idx == m.selected {
+		return m.switchTo(typ), nil
+	}
+	now, elapsed := time.Now(), m.stopwatch.Elapsed()
+	m = m.logStint(typ, now)
+	if typ == working {
+		m.tally[m.selected][0] += elapsed
+		m.bank = m.technique.worked(m.bank, elapsed)
+	} else {
+		m.tally[m.selected][1] += elapsed
+		m.bank = m.technique.rested(m.bank, elapsed)
+	}
+	m.selected = idx
+	m.log.send(entry{a: m.activities[m.selected], typ: selecting, t: now})
+	m.hook("select", selecting, false)
+	return m.switchTo(typ), m.stopwatch.Reset()
+}
@@ -0,0 +1,18 @@
+package main
+
+import "testing"
+
+func TestTreeSpacing(t *testing.T) {
+	activities := []string{"A / B", "A/B", "C"}
+	seen := make(map[int]bool)
+	for _, n := range tree(activities, map[string]bool{}, nil) {
+		if n.idx >= 0 {
+			seen[n.idx] = true
+		}
+	}
+	for i, a := range activities {
+		if !seen[i] {
+			t.Errorf("%q has no row in the tree", a)
+		}
+	}
+}